@@ -0,0 +1,182 @@
+package iprange
+
+import (
+	"net/netip"
+)
+
+// BlockIterator is an interface to iterate blocks of IP-addresses.
+type BlockIterator interface {
+	// Next returns true if there is at least one block left in the iterator
+	// and saves this block into the given pointer. If no blocks left returns false.
+	Next(*Range) bool
+
+	// Reset resets the iterator so it can be used again.
+	Reset()
+}
+
+// Blocks returns iterator of blocks of the range: for every prefix of length prefixLen
+// which overlaps the range, e.g. every /24, the iterator yields its intersection with the range.
+// Prefixes inside the range are yielded whole, prefixes at the edges or sparse octets ranges
+// are yielded partially. Blocks are yielded in ascending order, IPv4 first,
+// and do not overlap. Prefix length bigger than the length of IPv4 is limited to 32 for IPv4.
+// Returns nil if prefixLen is not in [0, 128].
+func Blocks(r Range, prefixLen int) BlockIterator {
+	if prefixLen < 0 || prefixLen > 8*16 {
+		return nil
+	}
+
+	it := &blockIterator{members: Ranges{r}.Normalize(), prefixLen: prefixLen}
+	it.Reset()
+	return it
+}
+
+type blockIterator struct {
+	members   Ranges
+	prefixLen int
+	next      netip.Addr // lowest address which can be in the next block
+	done      bool
+}
+
+var _ BlockIterator = &blockIterator{}
+
+func (it *blockIterator) Next(out *Range) bool {
+	for !it.done {
+		first, ok := it.ceil(it.next)
+		if !ok {
+			it.nextFamily(it.next)
+			continue
+		}
+
+		bits := it.prefixLen
+		if bits > first.BitLen() {
+			bits = first.BitLen()
+		}
+
+		block := cidr2range(first.AsSlice(), bits, first.BitLen())
+		last, _ := netip.AddrFromSlice(block.max)
+
+		// Members which overlap the block.
+		rr := make(Ranges, 0)
+		for _, m := range it.members {
+			if a, ok := m.(ceiler).ceil(first); ok && a.Compare(last) <= 0 {
+				rr = append(rr, m)
+			}
+		}
+
+		*out = Intersect(block, rr)
+
+		if it.next = last.Next(); !it.next.IsValid() {
+			it.nextFamily(last)
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func (it *blockIterator) Reset() {
+	it.next = netip.IPv4Unspecified()
+	it.done = len(it.members) == 0
+}
+
+// nextFamily moves the iterator to IPv6 addresses if addr is IPv4,
+// there are no more addresses otherwise.
+func (it *blockIterator) nextFamily(addr netip.Addr) {
+	if addr.Is4() {
+		it.next = netip.IPv6Unspecified()
+		return
+	}
+	it.done = true
+}
+
+// ceil returns the lowest address of the members which is not less than addr.
+func (it *blockIterator) ceil(addr netip.Addr) (netip.Addr, bool) {
+	var res netip.Addr
+	for _, m := range it.members {
+		if a, ok := m.(ceiler).ceil(addr); ok && (!res.IsValid() || a.Less(res)) {
+			res = a
+		}
+	}
+	return res, res.IsValid()
+}
+
+// ceiler is implemented by ranges which can find their lowest address
+// which is not less than the given one.
+type ceiler interface {
+	// ceil returns the lowest address of the range which is not less than addr,
+	// false if there is no such address or addr is from another family.
+	ceil(addr netip.Addr) (netip.Addr, bool)
+}
+
+var (
+	_ ceiler = singleRange{}
+	_ ceiler = minMaxRange{}
+	_ ceiler = octetsRange{}
+)
+
+func (r singleRange) ceil(addr netip.Addr) (netip.Addr, bool) {
+	ip, _ := netip.AddrFromSlice(r.IP)
+	ip = ip.Unmap()
+	return ip, ip.BitLen() == addr.BitLen() && addr.Compare(ip) <= 0
+}
+
+func (r minMaxRange) ceil(addr netip.Addr) (netip.Addr, bool) {
+	min, _ := netip.AddrFromSlice(r.min)
+	max, _ := netip.AddrFromSlice(r.max)
+
+	switch {
+	case addr.BitLen() != min.BitLen() || addr.Compare(max) > 0:
+		return netip.Addr{}, false
+	case addr.Less(min):
+		return min, true
+	}
+	return addr, true
+}
+
+func (r octetsRange) ceil(addr netip.Addr) (netip.Addr, bool) {
+	// IPv4-mapped addresses belong to IPv6 ranges here.
+	octs, n := addrOctets(addr)
+	if n != len(r.octets) {
+		return netip.Addr{}, false
+	}
+
+	// First octet which is not in the bounds.
+	k := 0
+	for k < n {
+		if _, ok := boundsIndex(r.octets[k], octs[k]); !ok {
+			break
+		}
+		k++
+	}
+
+	if k == n {
+		return addr, true
+	}
+
+	// Increase the last possible octet not after k, the rest octets are the lowest.
+	for j := k; j >= 0; j-- {
+		if v, ok := boundsAbove(r.octets[j], octs[j]); ok {
+			octs[j] = v
+			for i := j + 1; i < n; i++ {
+				octs[i] = r.octets[i][0].lo
+			}
+			return octets2addr(octs[:n]), true
+		}
+	}
+
+	return netip.Addr{}, false
+}
+
+// returns the lowest value in bounds which is greater than v, false if there is none.
+func boundsAbove(bb []ipOctet, v uint16) (uint16, bool) {
+	for _, b := range bb {
+		if b.hi > v {
+			if b.lo > v {
+				return b.lo, true
+			}
+			return v + 1, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,150 @@
+package iprange_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestBlocks(t *testing.T) {
+	tests := []struct {
+		ranges    []string
+		prefixLen int
+		blocks    []string
+	}{
+		{
+			[]string{"10.0.0.0/22"},
+			24,
+			[]string{"10.0.0.0/24", "10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
+		},
+		{
+			[]string{"10.0.0.250_10.0.2.5"},
+			24,
+			[]string{"10.0.0.250_10.0.0.255", "10.0.1.0/24", "10.0.2.0_10.0.2.5"},
+		},
+		{
+			[]string{"10.0.0.0/24"},
+			16,
+			[]string{"10.0.0.0/24"},
+		},
+		{
+			[]string{"10.0.1,3.1-3"},
+			24,
+			[]string{"10.0.1.1_10.0.1.3", "10.0.3.1_10.0.3.3"},
+		},
+		{
+			[]string{"10.0-1.0.1,5"},
+			24,
+			[]string{"10.0.0.1,5", "10.1.0.1,5"},
+		},
+		{
+			[]string{"10.0.0-255.1"},
+			20,
+			[]string{"10.0.0-15.1", "10.0.16-31.1", "10.0.32-47.1", "10.0.48-63.1",
+				"10.0.64-79.1", "10.0.80-95.1", "10.0.96-111.1", "10.0.112-127.1",
+				"10.0.128-143.1", "10.0.144-159.1", "10.0.160-175.1", "10.0.176-191.1",
+				"10.0.192-207.1", "10.0.208-223.1", "10.0.224-239.1", "10.0.240-255.1"},
+		},
+		{
+			[]string{"10.0.0.0/24", "10.0.0.5", "10.0.0.0/30"},
+			24,
+			[]string{"10.0.0.0/24"},
+		},
+		{
+			[]string{"10.0.1.0/24", "10.0.0.7", "10.0.0.1"},
+			24,
+			[]string{"10.0.0.1,7", "10.0.1.0/24"},
+		},
+		{
+			[]string{"2001:db8::/127", "10.0.0.1"},
+			24,
+			[]string{"10.0.0.1", "2001:db8::/127"},
+		},
+		{
+			[]string{"2001:db8::/126"},
+			128,
+			[]string{"2001:db8::", "2001:db8::1", "2001:db8::2", "2001:db8::3"},
+		},
+		{
+			[]string{"10.0.0.0/31"},
+			64,
+			[]string{"10.0.0.0", "10.0.0.1"},
+		},
+		{
+			[]string{"::1", "255.255.255.0/24"},
+			0,
+			[]string{"255.255.255.0/24", "::1"},
+		},
+		{
+			[]string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff0-ffff"},
+			124,
+			[]string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fff0/124"},
+		},
+		{
+			[]string{},
+			24,
+			[]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%d", strings.Join(tt.ranges, ","), tt.prefixLen), func(t *testing.T) {
+			it := iprange.Blocks(parseRanges(t, tt.ranges...), tt.prefixLen)
+			require.NotNil(t, it)
+
+			assert.Equal(t, tt.blocks, collectBlocks(it))
+
+			it.Reset()
+			assert.Equal(t, tt.blocks, collectBlocks(it), "after reset")
+		})
+	}
+}
+
+func TestBlocksHuge(t *testing.T) {
+	it := iprange.Blocks(iprange.Parse("2001:db8::/48"), 64)
+
+	blocks := collectBlocks(it)
+	require.Len(t, blocks, 1<<16)
+	assert.Equal(t, "2001:db8::/64", blocks[0])
+	assert.Equal(t, "2001:db8:0:1::/64", blocks[1])
+	assert.Equal(t, "2001:db8:0:ffff::/64", blocks[len(blocks)-1])
+}
+
+func TestBlocksMapped(t *testing.T) {
+	r := parseRanges(t, "::ffff:a00:0/119", "::ffff:b00:0-5", "10.0.0.1")
+	it := iprange.Blocks(r, 120)
+
+	// Blocks of IPv4-mapped addresses stay IPv6.
+	blocks := make([]string, 0)
+	var b iprange.Range
+	for i := 0; i < 10 && it.Next(&b); i++ {
+		blocks = append(blocks, fmt.Sprintf("%s=%s", b, b.Count()))
+	}
+	assert.Equal(t, []string{
+		"10.0.0.1=1",
+		"::ffff:a00:0/120=256",
+		"::ffff:a00:100/120=256",
+		"::ffff:b00:0_::ffff:b00:5=6",
+	}, blocks)
+}
+
+func TestBlocksInvalid(t *testing.T) {
+	r := iprange.Parse("10.0.0.0/24")
+	assert.Nil(t, iprange.Blocks(r, -1))
+	assert.Nil(t, iprange.Blocks(r, 129))
+}
+
+// collectBlocks returns all blocks of the iterator as strings.
+func collectBlocks(it iprange.BlockIterator) []string {
+	res := make([]string, 0)
+	var r iprange.Range
+	for it.Next(&r) {
+		res = append(res, fmt.Sprint(r))
+	}
+	return res
+}
@@ -0,0 +1,190 @@
+package iprange
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math/big"
+)
+
+// ErrInvalidCheckpoint is returned by Resume if the checkpoint is malformed
+// or was made by an iterator of another range.
+var ErrInvalidCheckpoint = errors.New("iprange: invalid checkpoint")
+
+// Checkpoint format version.
+const checkpointVersion = 1
+
+// Length of checkpoint header: version, kind, seed and fingerprint of the range.
+const checkpointHeaderLen = 18
+
+// Kinds of iterators in checkpoints.
+const (
+	checkpointSequential byte = iota
+	checkpointRandom
+	checkpointReverse
+)
+
+// CheckpointIterator is implemented by iterators which can save their state.
+// Iterators of the package implement it.
+type CheckpointIterator interface {
+	Iterator
+
+	// Checkpoint returns opaque versioned state of the iterator,
+	// which can be used to continue iteration later with Resume.
+	Checkpoint() []byte
+}
+
+var (
+	_ CheckpointIterator = &singleIterator{}
+	_ CheckpointIterator = &minMaxIterator{}
+	_ CheckpointIterator = &octetsIterator{}
+	_ CheckpointIterator = &rangesIterator{}
+	_ CheckpointIterator = &randomIterator{}
+	_ CheckpointIterator = &splitRandomIterator{}
+)
+
+// Checkpoint returns opaque versioned state of the iterator, which can be used
+// to continue iteration later with Resume. Returns nil if the iterator
+// does not implement CheckpointIterator.
+func Checkpoint(it Iterator) []byte {
+	if c, ok := it.(CheckpointIterator); ok {
+		return c.Checkpoint()
+	}
+	return nil
+}
+
+// Resume returns iterator of the range which continues from the checkpoint
+// made by Checkpoint of an iterator of the same range.
+// The resumed iterator yields the same addresses in the same order
+// as the original one would, including random order iterators.
+func Resume(r Range, checkpoint []byte) (Iterator, error) {
+	kind, seed, sum, pos, count, err := decodeCheckpoint(checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	var it Iterator
+	switch kind {
+	case checkpointSequential:
+		it = r.Iterator()
+	case checkpointRandom:
+		it = RandomIterator(r, seed)
+	case checkpointReverse:
+		it = ReverseIterator(r)
+	default:
+		return nil, ErrInvalidCheckpoint
+	}
+
+	s, ok := it.(seeker)
+	if !ok || fingerprint(it) != sum || it.Count().Cmp(count) != 0 || pos.Cmp(count) > 0 {
+		return nil, ErrInvalidCheckpoint
+	}
+
+	s.seek(pos)
+
+	return it, nil
+}
+
+// sequentialKind returns kind of the sequential iterator in checkpoints.
+func sequentialKind(reverse bool) byte {
+	if reverse {
+		return checkpointReverse
+	}
+	return checkpointSequential
+}
+
+// fingerprint returns hash of the canonical form of the range iterated by the iterator.
+func fingerprint(it Iterator) uint64 {
+	h := fnv.New64a()
+	writeRange(h, it)
+	return h.Sum64()
+}
+
+// writeRange writes the canonical form of the range iterated by the iterator.
+func writeRange(w io.Writer, it Iterator) {
+	switch it := it.(type) {
+	case *singleIterator:
+		fmt.Fprint(w, it.singleRange)
+	case *minMaxIterator:
+		fmt.Fprint(w, it.minMaxRange)
+	case *octetsIterator:
+		fmt.Fprint(w, it.octetsRange)
+	case *rangesIterator:
+		for _, i := range it.its {
+			writeRange(w, i)
+			fmt.Fprint(w, " ")
+		}
+	case *randomIterator:
+		fmt.Fprint(w, it.Ranges)
+	case *splitRandomIterator:
+		writeRange(w, it.rangesIterator)
+	}
+}
+
+// encodeCheckpoint returns checkpoint of the iterator: version, kind, seed, fingerprint of the range,
+// position and count of addresses prefixed with their lengths.
+func encodeCheckpoint(it Iterator, kind byte, seed uint64, pos, count *big.Int) []byte {
+	b := make([]byte, checkpointHeaderLen)
+	b[0], b[1] = checkpointVersion, kind
+	binary.BigEndian.PutUint64(b[2:], seed)
+	binary.BigEndian.PutUint64(b[10:], fingerprint(it))
+	for _, n := range []*big.Int{pos, count} {
+		nb := n.Bytes()
+		b = append(b, byte(len(nb)))
+		b = append(b, nb...)
+	}
+	return b
+}
+
+// decodeCheckpoint returns kind, seed, fingerprint of the range, position and count of addresses from checkpoint.
+func decodeCheckpoint(b []byte) (kind byte, seed, sum uint64, pos, count *big.Int, err error) {
+	if len(b) < checkpointHeaderLen || b[0] != checkpointVersion {
+		return 0, 0, 0, nil, nil, ErrInvalidCheckpoint
+	}
+
+	kind = b[1]
+	seed = binary.BigEndian.Uint64(b[2:10])
+	sum = binary.BigEndian.Uint64(b[10:checkpointHeaderLen])
+	b = b[checkpointHeaderLen:]
+
+	nn := make([]*big.Int, 2)
+	for i := range nn {
+		if len(b) < 1 || len(b) < 1+int(b[0]) {
+			return 0, 0, 0, nil, nil, ErrInvalidCheckpoint
+		}
+		nn[i] = new(big.Int).SetBytes(b[1 : 1+int(b[0])])
+		b = b[1+int(b[0]):]
+	}
+
+	if len(b) != 0 {
+		return 0, 0, 0, nil, nil, ErrInvalidCheckpoint
+	}
+
+	return kind, seed, sum, nn[0], nn[1], nil
+}
+
+func (it *singleIterator) Checkpoint() []byte {
+	return encodeCheckpoint(it, checkpointSequential, 0, it.position(), it.Count())
+}
+
+func (it *minMaxIterator) Checkpoint() []byte {
+	return encodeCheckpoint(it, sequentialKind(it.reverse), 0, it.position(), it.Count())
+}
+
+func (it *octetsIterator) Checkpoint() []byte {
+	return encodeCheckpoint(it, sequentialKind(it.reverse), 0, it.position(), it.Count())
+}
+
+func (it *rangesIterator) Checkpoint() []byte {
+	return encodeCheckpoint(it, sequentialKind(it.reverse), 0, it.position(), it.Count())
+}
+
+func (it *randomIterator) Checkpoint() []byte {
+	return encodeCheckpoint(it, checkpointRandom, it.seed, it.position(), it.Count())
+}
+
+func (it *splitRandomIterator) Checkpoint() []byte {
+	return encodeCheckpoint(it, checkpointRandom, it.seed, it.position(), it.Count())
+}
@@ -0,0 +1,106 @@
+package iprange_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestCheckpoint(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.250_10.0.1.5"},
+		{"10.0.1-3,7.1-3,20-22"},
+		{"2001:db8::1-2:fffe-ffff,1-2"},
+		{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/126"},
+	}
+
+	iterators := []struct {
+		name string
+		new  func(r iprange.Range) iprange.Iterator
+	}{
+		{"sequential", func(r iprange.Range) iprange.Iterator { return r.Iterator() }},
+		{"random", func(r iprange.Range) iprange.Iterator { return iprange.RandomIterator(r, 7) }},
+		{"reverse", iprange.ReverseIterator},
+	}
+
+	for i, tt := range tests {
+		for _, itt := range iterators {
+			t.Run(fmt.Sprintf("%d/%s/%s", i, itt.name, strings.Join(tt, ",")), func(t *testing.T) {
+				rr := parseRanges(t, tt...)
+				expected := collect(itt.new(rr))
+
+				for n := 0; n <= len(expected); n++ {
+					it := itt.new(rr)
+
+					res := make([]string, 0)
+					var ip net.IP
+					for j := 0; j < n && it.Next(&ip); j++ {
+						res = append(res, ip.String())
+					}
+
+					resumed, err := iprange.Resume(rr, iprange.Checkpoint(it))
+					require.NoError(t, err)
+
+					res = append(res, collect(resumed)...)
+					assert.Equal(t, expected, res, "interrupted after %d", n)
+				}
+			})
+		}
+	}
+}
+
+func TestCheckpointHuge(t *testing.T) {
+	r := iprange.Ranges{iprange.Parse("::/0"), iprange.Parse("10.0.0.0/30")}
+
+	it := iprange.RandomIterator(r, 1)
+	var ip net.IP
+	for i := 0; i < 10; i++ {
+		it.Next(&ip)
+	}
+
+	resumed, err := iprange.Resume(r, iprange.Checkpoint(it))
+	require.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		var a, b net.IP
+		require.True(t, it.Next(&a))
+		require.True(t, resumed.Next(&b))
+		assert.Equal(t, a, b)
+	}
+}
+
+func TestCheckpointInvalid(t *testing.T) {
+	it := iprange.Parse("10.0.0.0/24").Iterator()
+
+	tests := []struct {
+		r          iprange.Range
+		checkpoint []byte
+	}{
+		{iprange.Parse("10.0.0.0/24"), nil},
+		{iprange.Parse("10.0.0.0/24"), []byte("invalid checkpoint")},
+		{iprange.Parse("10.0.0.0/24"), iprange.Checkpoint(it)[:11]},
+		{iprange.Parse("10.0.0.0/25"), iprange.Checkpoint(it)},
+		{iprange.Parse("192.168.5.0/24"), iprange.Checkpoint(it)},
+		{iprange.Parse("10.1.0-255.0"), iprange.Checkpoint(iprange.Parse("10.2.0-255.0").Iterator())},
+		{iprange.Parse("10.0.0.0/24"), iprange.Checkpoint(iprange.RandomIterator(iprange.Parse("10.0.1.0/24"), 1))},
+		{
+			iprange.Ranges{iprange.Parse("10.0.0.1"), iprange.Parse("10.0.0.2")},
+			iprange.Checkpoint(iprange.Ranges{iprange.Parse("10.0.0.1"), iprange.Parse("10.0.0.3")}.Iterator()),
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			_, err := iprange.Resume(tt.r, tt.checkpoint)
+			assert.True(t, errors.Is(err, iprange.ErrInvalidCheckpoint))
+		})
+	}
+}
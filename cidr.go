@@ -0,0 +1,82 @@
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"sort"
+)
+
+// ToCIDRs returns the smallest list of CIDR prefixes which cover exactly
+// the addresses of the range. Prefixes are sorted, IPv4 first.
+func ToCIDRs(r Range) []*net.IPNet {
+	ivs := make([]interval, 0)
+	for _, b := range disjoint(boxesOf(r)) {
+		ivs = append(ivs, b.intervals()...)
+	}
+
+	// IPv4 first, then by the lowest address.
+	sort.Slice(ivs, func(i, j int) bool {
+		if len(ivs[i].lo) != len(ivs[j].lo) {
+			return len(ivs[i].lo) < len(ivs[j].lo)
+		}
+		return octcmp(ivs[i].lo, ivs[j].lo) < 0
+	})
+
+	res := make([]*net.IPNet, 0)
+
+	for i := 0; i < len(ivs); {
+		lo, hi := ivs[i].lo, ivs[i].hi
+
+		// Join adjacent intervals.
+		j := i + 1
+		for ; j < len(ivs) && len(ivs[j].lo) == len(hi); j++ {
+			next, ok := octinc(hi, octtop(len(hi)))
+			if !ok || octcmp(next, ivs[j].lo) != 0 {
+				break
+			}
+			hi = ivs[j].hi
+		}
+		i = j
+
+		res = append(res, interval2cidrs(octets2ip(lo), octets2ip(hi))...)
+	}
+
+	return res
+}
+
+// interval2cidrs returns the smallest list of CIDR prefixes
+// which cover addresses between lo and hi inclusive.
+func interval2cidrs(lo, hi net.IP) []*net.IPNet {
+	bits := 8 * len(lo)
+	one := big.NewInt(1)
+
+	cur, last := ip2big(lo), ip2big(hi)
+	res := make([]*net.IPNet, 0)
+
+	for cur.Cmp(last) <= 0 {
+		// Biggest block aligned at cur.
+		k := int(cur.TrailingZeroBits())
+		if cur.Sign() == 0 || k > bits {
+			k = bits
+		}
+
+		// Which does not exceed last.
+		end := new(big.Int)
+		for ; k > 0; k-- {
+			end.Lsh(one, uint(k))
+			end.Add(end, cur)
+			end.Sub(end, one)
+			if end.Cmp(last) <= 0 {
+				break
+			}
+		}
+
+		ip := make(net.IP, len(lo))
+		cur.FillBytes(ip)
+		res = append(res, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits-k, bits)})
+
+		cur.Add(cur, new(big.Int).Lsh(one, uint(k)))
+	}
+
+	return res
+}
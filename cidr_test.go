@@ -0,0 +1,92 @@
+package iprange_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/russtone/iprange"
+)
+
+func TestToCIDRs(t *testing.T) {
+	tests := []struct {
+		ss    []string
+		cidrs []string
+	}{
+		//
+		// IPv4
+		//
+
+		// single
+		{[]string{"192.168.1.1"}, []string{"192.168.1.1/32"}},
+
+		// cidr
+		{[]string{"192.168.1.10/24"}, []string{"192.168.1.0/24"}},
+		{[]string{"0.0.0.0/0"}, []string{"0.0.0.0/0"}},
+
+		// begin_end
+		{[]string{"10.0.0.7_10.0.1.200"}, []string{
+			"10.0.0.7/32",
+			"10.0.0.8/29",
+			"10.0.0.16/28",
+			"10.0.0.32/27",
+			"10.0.0.64/26",
+			"10.0.0.128/25",
+			"10.0.1.0/25",
+			"10.0.1.128/26",
+			"10.0.1.192/29",
+			"10.0.1.200/32",
+		}},
+		{[]string{"10.0.0.0_10.0.3.255"}, []string{"10.0.0.0/22"}},
+
+		// octets
+		{[]string{"192.168.1,3-5.1-10"}, []string{
+			"192.168.1.1/32", "192.168.1.2/31", "192.168.1.4/30", "192.168.1.8/31", "192.168.1.10/32",
+			"192.168.3.1/32", "192.168.3.2/31", "192.168.3.4/30", "192.168.3.8/31", "192.168.3.10/32",
+			"192.168.4.1/32", "192.168.4.2/31", "192.168.4.4/30", "192.168.4.8/31", "192.168.4.10/32",
+			"192.168.5.1/32", "192.168.5.2/31", "192.168.5.4/30", "192.168.5.8/31", "192.168.5.10/32",
+		}},
+		{[]string{"10.0-1.0-255.0-255"}, []string{"10.0.0.0/15"}},
+		{[]string{"10.0.0,2.0-255"}, []string{"10.0.0.0/24", "10.0.2.0/24"}},
+
+		//
+		// IPv6
+		//
+
+		{[]string{"2001:db8::/32"}, []string{"2001:db8::/32"}},
+		{[]string{"2001:db8::1_2001:db8::6"}, []string{
+			"2001:db8::1/128",
+			"2001:db8::2/127",
+			"2001:db8::4/127",
+			"2001:db8::6/128",
+		}},
+		{[]string{"2001:db8::_2001:db8:1:ffff:ffff:ffff:ffff:ffff"}, []string{"2001:db8::/47"}},
+		{[]string{"2001:db8:0-3::"}, []string{
+			"2001:db8::/128",
+			"2001:db8:1::/128",
+			"2001:db8:2::/128",
+			"2001:db8:3::/128",
+		}},
+
+		//
+		// Multiple
+		//
+
+		{[]string{"10.0.0.0/25", "10.0.0.128/25", "10.0.0.0/26"}, []string{"10.0.0.0/24"}},
+		{[]string{"2001:db8::/127", "10.0.0.2-3", "10.0.0.0/31"}, []string{"10.0.0.0/30", "2001:db8::/127"}},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, strings.Join(tt.ss, ",")), func(t *testing.T) {
+			rr := parseRanges(t, tt.ss...)
+
+			cidrs := make([]string, 0)
+			for _, n := range iprange.ToCIDRs(rr) {
+				cidrs = append(cidrs, n.String())
+			}
+			assert.Equal(t, tt.cidrs, cidrs)
+		})
+	}
+}
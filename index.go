@@ -0,0 +1,327 @@
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// At returns the address with index i in the iteration order of the range,
+// false if there is no such index. Time does not depend on the range size.
+func At(r Range, i *big.Int) (net.IP, bool) {
+	if i.Sign() < 0 {
+		return nil, false
+	}
+
+	rest := new(big.Int).Set(i)
+	for _, m := range flatten(Ranges{r}) {
+		n := count(m)
+		if rest.Cmp(n) < 0 {
+			j, _ := u128FromBig(rest)
+			return m.at(j).AsSlice(), true
+		}
+		rest.Sub(rest, n)
+	}
+
+	return nil, false
+}
+
+// IndexOf returns index of the address in the iteration order of the range,
+// false if the range does not contain the address. If the range is Ranges
+// with overlapping members, the index of the first occurrence is returned.
+// Time does not depend on the range size.
+func IndexOf(r Range, ip net.IP) (*big.Int, bool) {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return nil, false
+	}
+
+	offset := big.NewInt(0)
+	for _, m := range flatten(Ranges{r}) {
+		if j, ok := m.indexOf(addr); ok {
+			return offset.Add(offset, j.big()), true
+		}
+		offset.Add(offset, count(m))
+	}
+
+	return nil, false
+}
+
+// indexer is implemented by ranges which can map indexes of their addresses
+// in the iteration order to the addresses and back.
+type indexer interface {
+	// lastIndex returns index of the last address of the range.
+	lastIndex() uint128
+
+	// at returns address with the given index,
+	// index must not be greater than the last one.
+	at(i uint128) netip.Addr
+
+	// indexOf returns index of the address, false if the address is not in the range.
+	indexOf(addr netip.Addr) (uint128, bool)
+
+	// slice returns range of addresses with indexes from lo to hi inclusive,
+	// in the same order.
+	slice(lo, hi uint128) Range
+}
+
+var (
+	_ indexer = singleRange{}
+	_ indexer = minMaxRange{}
+	_ indexer = octetsRange{}
+)
+
+func (r singleRange) lastIndex() uint128 {
+	return uint128{}
+}
+
+func (r singleRange) at(i uint128) netip.Addr {
+	addr, _ := netip.AddrFromSlice(r.IP)
+	return addr
+}
+
+func (r singleRange) indexOf(addr netip.Addr) (uint128, bool) {
+	return uint128{}, r.ContainsAddr(addr)
+}
+
+func (r singleRange) slice(lo, hi uint128) Range {
+	return &singleRange{r.IP}
+}
+
+func (r minMaxRange) lastIndex() uint128 {
+	return u128FromIP(r.max).sub(u128FromIP(r.min))
+}
+
+func (r minMaxRange) at(i uint128) netip.Addr {
+	return u128FromIP(r.min).add(i).addr(len(r.min))
+}
+
+func (r minMaxRange) indexOf(addr netip.Addr) (uint128, bool) {
+	if !r.ContainsAddr(addr) {
+		return uint128{}, false
+	}
+	return u128FromAddr(addr).sub(u128FromIP(r.min)), true
+}
+
+func (r minMaxRange) slice(lo, hi uint128) Range {
+	min := make(net.IP, len(r.min))
+	max := make(net.IP, len(r.max))
+	u128FromIP(r.min).add(lo).putIP(min)
+	u128FromIP(r.min).add(hi).putIP(max)
+	return &minMaxRange{min, max}
+}
+
+// Addresses of octets range are numbered in mixed radix,
+// every octet is a digit with base equal to the number of values in its bounds.
+
+func (r octetsRange) lastIndex() uint128 {
+	i, _ := r.indexOf(octets2addr(r.octets.max()))
+	return i
+}
+
+func (r octetsRange) at(i uint128) netip.Addr {
+	var octs [net.IPv6len / 2]uint16
+
+	for j := len(r.octets) - 1; j >= 0; j-- {
+		var d uint64
+		i, d = i.divmod64(boundsSize(r.octets[j]))
+		octs[j] = boundsValue(r.octets[j], d)
+	}
+
+	return octets2addr(octs[:len(r.octets)])
+}
+
+func (r octetsRange) indexOf(addr netip.Addr) (uint128, bool) {
+	octs, n := addr2octets(addr)
+	if n != len(r.octets) {
+		return uint128{}, false
+	}
+
+	var i uint128
+
+	for j, bb := range r.octets {
+		d, ok := boundsIndex(bb, octs[j])
+		if !ok {
+			return uint128{}, false
+		}
+		i = i.mul64(boundsSize(bb)).add64(d)
+	}
+
+	return i, true
+}
+
+func (r octetsRange) slice(lo, hi uint128) Range {
+	n := len(r.octets)
+
+	// Digits of the indexes.
+	dlo := make([]uint16, n)
+	dhi := make([]uint16, n)
+	tops := make([]uint16, n)
+
+	for j := n - 1; j >= 0; j-- {
+		size := boundsSize(r.octets[j])
+		var d uint64
+		lo, d = lo.divmod64(size)
+		dlo[j] = uint16(d)
+		hi, d = hi.divmod64(size)
+		dhi[j] = uint16(d)
+		tops[j] = uint16(size - 1)
+	}
+
+	// Boxes of digits to boxes of octets.
+	rr := make(Ranges, 0)
+	for _, box := range interval2boxes(dlo, dhi, tops) {
+		octs := make(ipOctets, n)
+		for j, b := range box {
+			octs[j] = boundsSlice(r.octets[j], uint64(b[0].lo), uint64(b[0].hi))
+		}
+		rr = append(rr, box2range(octs))
+	}
+
+	if len(rr) == 1 {
+		return rr[0]
+	}
+
+	return rr
+}
+
+// returns number of addresses of the range.
+func count(r indexer) *big.Int {
+	n := r.lastIndex().big()
+	return n.Add(n, big.NewInt(1))
+}
+
+// returns number of values in bounds.
+func boundsSize(bb []ipOctet) uint64 {
+	var n uint64
+	for _, b := range bb {
+		n += uint64(b.hi) - uint64(b.lo) + 1
+	}
+	return n
+}
+
+// returns d-th value in bounds.
+func boundsValue(bb []ipOctet, d uint64) uint16 {
+	_, v := boundsLocate(bb, d)
+	return v
+}
+
+// returns index of the bound with d-th value and the value.
+func boundsLocate(bb []ipOctet, d uint64) (int, uint16) {
+	for i, b := range bb {
+		n := uint64(b.hi) - uint64(b.lo) + 1
+		if d < n {
+			return i, b.lo + uint16(d)
+		}
+		d -= n
+	}
+	return 0, 0
+}
+
+// returns bounds of values from lo-th to hi-th inclusive.
+func boundsSlice(bb []ipOctet, lo, hi uint64) []ipOctet {
+	res := make([]ipOctet, 0)
+
+	var d uint64 // index of the first value of the bound
+	for _, b := range bb {
+		n := uint64(b.hi) - uint64(b.lo) + 1
+		s, e := lo, hi
+		if s < d {
+			s = d
+		}
+		if e > d+n-1 {
+			e = d + n - 1
+		}
+		if s <= e {
+			res = append(res, ipOctet{b.lo + uint16(s-d), b.lo + uint16(e-d)})
+		}
+		d += n
+	}
+
+	return res
+}
+
+// returns index of value in bounds, false if bounds do not contain the value.
+func boundsIndex(bb []ipOctet, v uint16) (uint64, bool) {
+	var d uint64
+	for _, b := range bb {
+		if v >= b.lo && v <= b.hi {
+			return d + uint64(v-b.lo), true
+		}
+		d += uint64(b.hi) - uint64(b.lo) + 1
+	}
+	return 0, false
+}
+
+// indexedRanges maps indexes of addresses of multiple ranges
+// in the iteration order to the addresses and back.
+type indexedRanges struct {
+	ranges  []indexer
+	offsets []uint128 // index of the first address of every range
+	last    uint128   // index of the last address
+}
+
+// newIndexedRanges returns indexed ranges,
+// false if the total number of addresses does not fit into 128 bits.
+func newIndexedRanges(rr Ranges) (*indexedRanges, bool) {
+	ir := &indexedRanges{}
+
+	var next uint128 // index of the first address of the next range
+	overflow := false
+
+	for _, r := range flatten(rr) {
+		if overflow {
+			return nil, false
+		}
+		ir.ranges = append(ir.ranges, r)
+		ir.offsets = append(ir.offsets, next)
+		if ir.last, overflow = next.addOverflow(r.lastIndex()); overflow {
+			return nil, false
+		}
+		next, overflow = ir.last.addOverflow(uint128{0, 1})
+	}
+
+	return ir, true
+}
+
+// empty returns true if there are no addresses.
+func (ir *indexedRanges) empty() bool {
+	return len(ir.ranges) == 0
+}
+
+func (ir *indexedRanges) at(i uint128) netip.Addr {
+	k := sort.Search(len(ir.offsets), func(k int) bool {
+		return ir.offsets[k].cmp(i) == 1
+	}) - 1
+	return ir.ranges[k].at(i.sub(ir.offsets[k]))
+}
+
+func (ir *indexedRanges) indexOf(addr netip.Addr) (uint128, bool) {
+	for k, r := range ir.ranges {
+		if i, ok := r.indexOf(addr); ok {
+			return ir.offsets[k].add(i), true
+		}
+	}
+	return uint128{}, false
+}
+
+// flatten returns members of ranges as indexers.
+// Nested ranges are expanded, ranges implemented outside of the package
+// are converted to the ranges of the package, so their order may change.
+func flatten(rr Ranges) []indexer {
+	res := make([]indexer, 0, len(rr))
+	for _, r := range rr {
+		switch r := r.(type) {
+		case Ranges:
+			res = append(res, flatten(r)...)
+		case *IPSet:
+			res = append(res, flatten(r.Ranges())...)
+		case indexer:
+			res = append(res, r)
+		default:
+			res = append(res, flatten(Ranges{fromBoxes(disjoint(boxesOf(r)))})...)
+		}
+	}
+	return res
+}
@@ -0,0 +1,85 @@
+package iprange_test
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestAtIndexOf(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"0.0.0.5_0.0.0.9"},
+		{"10.0.0.250_10.0.1.5"},
+		{"10.0.1-3,7.1-3,20-22"},
+		{"2001:db8::fffe_2001:db8::1:2"},
+		{"2001:db8::1-2:fffe-ffff,1-2"},
+		{"10.0.0.1", "10.0.0.5-7", "2001:db8::/126"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, strings.Join(tt, ",")), func(t *testing.T) {
+			rr := parseRanges(t, tt...)
+
+			it := rr.Iterator()
+			var ip net.IP
+			var n int64
+			for ; it.Next(&ip); n++ {
+				res, ok := iprange.At(rr, big.NewInt(n))
+				require.True(t, ok)
+				assert.Equal(t, ip.String(), res.String())
+
+				idx, ok := iprange.IndexOf(rr, ip)
+				require.True(t, ok)
+				assert.Equal(t, big.NewInt(n), idx)
+
+				idx, ok = iprange.IndexOf(rr, net.ParseIP(ip.String()))
+				require.True(t, ok)
+				assert.Equal(t, big.NewInt(n), idx, "16-byte")
+			}
+
+			_, ok := iprange.At(rr, big.NewInt(n))
+			assert.False(t, ok)
+
+			_, ok = iprange.At(rr, big.NewInt(-1))
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestAtHuge(t *testing.T) {
+	r := iprange.Ranges{iprange.Parse("10.0.0.0/8"), iprange.Parse("::/0")}
+
+	i := new(big.Int).Lsh(big.NewInt(1), 128)
+	i.Add(i, big.NewInt(1<<24-1))
+
+	ip, ok := iprange.At(r, i)
+	require.True(t, ok)
+	assert.Equal(t, "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", ip.String())
+
+	idx, ok := iprange.IndexOf(r, ip)
+	require.True(t, ok)
+	assert.Equal(t, i, idx)
+
+	_, ok = iprange.At(r, i.Add(i, big.NewInt(1)))
+	assert.False(t, ok)
+}
+
+func TestIndexOfMissing(t *testing.T) {
+	r := iprange.Parse("10.0.1-3.1-10")
+
+	for _, s := range []string{"10.0.4.1", "10.0.1.11", "2001:db8::1"} {
+		_, ok := iprange.IndexOf(r, net.ParseIP(s))
+		assert.False(t, ok, s)
+	}
+
+	_, ok := iprange.IndexOf(r, net.IP{1, 2})
+	assert.False(t, ok)
+}
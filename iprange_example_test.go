@@ -93,3 +93,151 @@ func ExampleRanges() {
 	// true
 	// true
 }
+
+func ExampleParseRange() {
+	_, err := iprange.ParseRange("192.168.300.1")
+	fmt.Println(err)
+
+	// Output:
+	// iprange: invalid range "192.168.300.1" at offset 8: octet > 255
+}
+
+func ExampleParseRange_mask() {
+	fmt.Println(iprange.Parse("192.168.1.0/255.255.255.0"))
+	fmt.Println(iprange.Parse("10.0.0.0 0.0.255.255"))
+	fmt.Println(iprange.Parse("10.0.0.1 0.0.1.6"))
+
+	// Output:
+	// 192.168.1.0/24
+	// 10.0.0.0/16
+	// 10.0.0-1.1,3,5,7
+}
+
+func ExampleSubtract() {
+	r := iprange.Subtract(iprange.Parse("10.0.0.0/16"), iprange.Parse("10.0.5.0/24"))
+
+	fmt.Println(r.Count())
+	fmt.Println(r.Contains(net.ParseIP("10.0.4.255")))
+	fmt.Println(r.Contains(net.ParseIP("10.0.5.1")))
+
+	// Output:
+	// 65280
+	// true
+	// false
+}
+
+func ExampleToCIDRs() {
+	for _, n := range iprange.ToCIDRs(iprange.Parse("10.0.0.6_10.0.0.17")) {
+		fmt.Println(n)
+	}
+
+	// Output:
+	// 10.0.0.6/31
+	// 10.0.0.8/29
+	// 10.0.0.16/31
+}
+
+func ExampleRandomIterator() {
+	r := iprange.Parse("10.0.0.0/16")
+
+	it := iprange.RandomIterator(r, 42)
+	var ip net.IP
+	for i := 0; i < 4 && it.Next(&ip); i++ {
+		fmt.Println(ip.String())
+	}
+
+	// Output:
+	// 10.0.49.175
+	// 10.0.7.231
+	// 10.0.121.30
+	// 10.0.232.238
+}
+
+func ExampleReverseIterator() {
+	r := iprange.Parse("10.0.1,2.254-255")
+
+	it := iprange.ReverseIterator(r)
+	var ip net.IP
+	for it.Next(&ip) {
+		fmt.Println(ip.String())
+	}
+
+	// Output:
+	// 10.0.2.255
+	// 10.0.2.254
+	// 10.0.1.255
+	// 10.0.1.254
+}
+
+func ExampleBlocks() {
+	it := iprange.Blocks(iprange.Parse("10.0.0.200_10.0.2.10"), 24)
+
+	var r iprange.Range
+	for it.Next(&r) {
+		fmt.Println(r, r.Count())
+	}
+
+	// Output:
+	// 10.0.0.200_10.0.0.255 56
+	// 10.0.1.0/24 256
+	// 10.0.2.0_10.0.2.10 11
+}
+
+func ExampleAll() {
+	for ip := range iprange.All(iprange.Parse("10.0.0.1,5,9")) {
+		fmt.Println(ip)
+	}
+
+	// Output:
+	// 10.0.0.1
+	// 10.0.0.5
+	// 10.0.0.9
+}
+
+func ExampleNewIPSet() {
+	s := iprange.NewIPSet(iprange.Ranges{
+		iprange.Parse("10.0.0.0/24"),
+		iprange.Parse("10.0.1.0/24"),
+		iprange.Parse("192.168.1,3.1-10"),
+	})
+
+	fmt.Println(s)
+	fmt.Println(s.Contains(net.ParseIP("10.0.1.17")))
+	fmt.Println(s.Contains(net.ParseIP("192.168.2.1")))
+
+	// Output:
+	// 10.0.0.0/23 192.168.1.1_192.168.1.10 192.168.3.1_192.168.3.10
+	// true
+	// false
+}
+
+func ExampleTable() {
+	var sites iprange.Table[string]
+
+	sites.Insert(iprange.Parse("10.0.0.0/8"), "corp")
+	sites.Insert(iprange.Parse("10.1.0.0/16"), "office")
+	sites.Insert(iprange.Parse("10.1.2.10_10.1.2.20"), "printers")
+
+	for _, s := range []string{"10.9.0.1", "10.1.0.1", "10.1.2.15", "192.168.0.1"} {
+		site, r, ok := sites.Lookup(net.ParseIP(s))
+		fmt.Println(s, site, r, ok)
+	}
+
+	// Output:
+	// 10.9.0.1 corp 10.0.0.0/8 true
+	// 10.1.0.1 office 10.1.0.0/16 true
+	// 10.1.2.15 printers 10.1.2.10_10.1.2.20 true
+	// 192.168.0.1  <nil> false
+}
+
+func ExampleParseList() {
+	rr, err := iprange.ParseList("10.0.0.0/24, 10.0.1.5 # gateway\n10.2.0.1-10")
+	fmt.Println(rr, err)
+
+	_, err = iprange.ParseList("10.0.0.0/24\n10.0.1.300")
+	fmt.Println(err)
+
+	// Output:
+	// 10.0.0.0/24 10.0.1.5 10.2.0.1-10 <nil>
+	// iprange: line 2, column 8: invalid range "10.0.1.300": octet > 255
+}
@@ -0,0 +1,196 @@
+package iprange
+
+import (
+	"encoding/binary"
+	"math/big"
+	"net"
+	"net/netip"
+	"sort"
+)
+
+// IPSet is an immutable set of IP-addresses optimized for membership checks.
+// Addresses are stored as sorted disjoint intervals, so Contains takes logarithmic time
+// and does not allocate. IPSet is safe for concurrent use.
+type IPSet struct {
+	v4, v6 []span
+}
+
+var _ Range = &IPSet{}
+
+// NewIPSet returns set of addresses of the range.
+// Octets ranges are stored as intervals of addresses, so the size of the set
+// depends on the number of contiguous parts of the range, not on the number of addresses.
+func NewIPSet(r Range) *IPSet {
+	s := &IPSet{}
+
+	for _, m := range flatten(Ranges{r}) {
+		if m.at(uint128{}).Is4() {
+			s.v4 = append(s.v4, m.(spanner).spans()...)
+		} else {
+			s.v6 = append(s.v6, m.(spanner).spans()...)
+		}
+	}
+
+	s.v4 = mergeSpans(s.v4)
+	s.v6 = mergeSpans(s.v6)
+
+	return s
+}
+
+// Contains checks if the given IP-address is in the set.
+func (s *IPSet) Contains(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		return spansContain(s.v4, uint128{0, uint64(binary.BigEndian.Uint32(ip4))})
+	}
+	if len(ip) == net.IPv6len {
+		return spansContain(s.v6, u128FromIP(ip))
+	}
+	return false
+}
+
+// ContainsAddr is like Contains, but takes netip.Addr.
+func (s *IPSet) ContainsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	switch {
+	case addr.Is4():
+		return spansContain(s.v4, u128FromAddr(addr))
+	case addr.Is6():
+		return spansContain(s.v6, u128FromAddr(addr))
+	}
+	return false
+}
+
+// Count returns number of addresses in the set.
+func (s *IPSet) Count() *big.Int {
+	c := big.NewInt(0)
+	one := big.NewInt(1)
+	for _, ss := range [][]span{s.v4, s.v6} {
+		for _, sp := range ss {
+			c.Add(c, sp.hi.sub(sp.lo).big())
+			c.Add(c, one)
+		}
+	}
+	return c
+}
+
+// Iterator returns iterator of the set, addresses are yielded in ascending order, IPv4 first.
+func (s *IPSet) Iterator() Iterator {
+	return s.Ranges().Iterator()
+}
+
+// Ranges returns the set as contiguous ranges in ascending order, IPv4 first.
+func (s *IPSet) Ranges() Ranges {
+	rr := make(Ranges, 0, len(s.v4)+len(s.v6))
+	for _, sp := range s.v4 {
+		rr = append(rr, sp.toRange(net.IPv4len))
+	}
+	for _, sp := range s.v6 {
+		rr = append(rr, sp.toRange(net.IPv6len))
+	}
+	return rr
+}
+
+// String returns contiguous ranges of the set separated by spaces.
+func (s *IPSet) String() string {
+	return s.Ranges().String()
+}
+
+func (s *IPSet) boxes() []ipOctets {
+	return s.Ranges().boxes()
+}
+
+// span is a contiguous range of IP-addresses between lo and hi inclusive, as integers.
+type span struct {
+	lo, hi uint128
+}
+
+// toRange returns range of addresses of the span, iplen is the IP length in bytes.
+func (sp span) toRange(iplen int) Range {
+	lo := make(net.IP, iplen)
+	sp.lo.putIP(lo)
+
+	if sp.lo == sp.hi {
+		return &singleRange{lo}
+	}
+
+	hi := make(net.IP, iplen)
+	sp.hi.putIP(hi)
+
+	return &minMaxRange{lo, hi}
+}
+
+// spanner is implemented by ranges which can be represented as sorted disjoint spans.
+type spanner interface {
+	spans() []span
+}
+
+var (
+	_ spanner = singleRange{}
+	_ spanner = minMaxRange{}
+	_ spanner = octetsRange{}
+)
+
+func (r singleRange) spans() []span {
+	x := u128FromAddr(r.at(uint128{}))
+	return []span{{x, x}}
+}
+
+func (r minMaxRange) spans() []span {
+	return []span{{u128FromIP(r.min), u128FromIP(r.max)}}
+}
+
+func (r octetsRange) spans() []span {
+	intervals := r.octets.intervals()
+	res := make([]span, len(intervals))
+	for i, in := range intervals {
+		res[i] = span{u128FromAddr(octets2addr(in.lo)), u128FromAddr(octets2addr(in.hi))}
+	}
+	return res
+}
+
+// mergeSpans sorts spans and merges overlapping and adjacent ones.
+func mergeSpans(ss []span) []span {
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].lo.cmp(ss[j].lo) < 0
+	})
+
+	res := make([]span, 0, len(ss))
+	for _, sp := range ss {
+		if n := len(res); n > 0 {
+			next, overflow := res[n-1].hi.addOverflow(uint128{0, 1})
+			if overflow || sp.lo.cmp(next) <= 0 {
+				if sp.hi.cmp(res[n-1].hi) > 0 {
+					res[n-1].hi = sp.hi
+				}
+				continue
+			}
+		}
+		res = append(res, sp)
+	}
+
+	return res
+}
+
+// overlaps checks if spans have common addresses.
+func (sp span) overlaps(other span) bool {
+	return sp.lo.cmp(other.hi) <= 0 && other.lo.cmp(sp.hi) <= 0
+}
+
+// spansContain checks if x is in one of sorted disjoint spans.
+func spansContain(ss []span, x uint128) bool {
+	// Binary search for the first span which does not end before x.
+	i, j := 0, len(ss)
+	for i < j {
+		h := int(uint(i+j) >> 1)
+		if ss[h].hi.cmp(x) < 0 {
+			i = h + 1
+		} else {
+			j = h
+		}
+	}
+	return i < len(ss) && ss[i].lo.cmp(x) <= 0
+}
+
+func (s *IPSet) reverseIterator() Iterator {
+	return s.Ranges().reverseIterator()
+}
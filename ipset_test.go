@@ -0,0 +1,131 @@
+package iprange_test
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/russtone/iprange"
+)
+
+func TestIPSet(t *testing.T) {
+	tests := []struct {
+		ranges []string
+		set    string
+	}{
+		{[]string{"10.0.0.1"}, "10.0.0.1"},
+		{[]string{"10.0.0.0/24", "10.0.1.0/24", "10.0.0.5"}, "10.0.0.0/23"},
+		{[]string{"10.0.0.250_10.0.1.5", "10.0.1.6_10.0.1.10"}, "10.0.0.250_10.0.1.10"},
+		{[]string{"10.0.1,3.1-3,5"}, "10.0.1.1_10.0.1.3 10.0.1.5 10.0.3.1_10.0.3.3 10.0.3.5"},
+		{[]string{"10.0.0-255.0-255"}, "10.0.0.0/16"},
+		{[]string{"2001:db8::1", "10.0.0.1", "2001:db8::/127"}, "10.0.0.1 2001:db8::/127"},
+		{[]string{"255.255.255.255", "255.255.255.0/24", "::/0"}, "255.255.255.0/24 ::/0"},
+		{[]string{}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.ranges, ","), func(t *testing.T) {
+			rr := parseRanges(t, tt.ranges...)
+			s := iprange.NewIPSet(rr)
+
+			assert.Equal(t, tt.set, s.String())
+			assert.Equal(t, rr.Normalize().Count(), s.Count())
+
+			ips := []string{"10.0.0.0", "10.0.0.2", "10.0.1.4", "10.0.2.0", "255.255.255.255", "2001:db8::2", "::"}
+			if s.Count().IsInt64() && s.Count().Int64() < 1000 {
+				assert.Equal(t, collect(rr.Normalize().Iterator()), collect(s.Iterator()))
+				ips = append(ips, addresses(rr)...)
+			}
+
+			for _, ip := range ips {
+				addr := netip.MustParseAddr(ip)
+				assert.Equal(t, rr.Contains(net.ParseIP(ip)), s.Contains(net.ParseIP(ip)), ip)
+				assert.Equal(t, rr.Contains(net.ParseIP(ip)), s.Contains(net.ParseIP(ip).To16()), ip)
+				assert.Equal(t, rr.ContainsAddr(addr), s.ContainsAddr(addr), ip)
+			}
+
+			assert.False(t, s.Contains(nil))
+			assert.False(t, s.ContainsAddr(netip.Addr{}))
+		})
+	}
+}
+
+func TestIPSetSetOperations(t *testing.T) {
+	s := iprange.NewIPSet(parseRanges(t, "10.0.0.0/24", "10.0.2.0/24"))
+
+	r := iprange.Subtract(s, iprange.Parse("10.0.0.128/25"))
+	assert.Equal(t, "10.0.0.0/25 10.0.2.0/24", fmt.Sprint(r))
+
+	_, ok := iprange.IndexOf(s, net.ParseIP("10.0.2.0"))
+	assert.True(t, ok)
+}
+
+func TestIPSetAllocs(t *testing.T) {
+	s := iprange.NewIPSet(parseRanges(t, "10.0.1,3.1-3,5", "2001:db8::/64"))
+	ip4 := net.ParseIP("10.0.3.5")
+	ip6 := net.ParseIP("2001:db8::1")
+	addr := netip.MustParseAddr("10.0.3.5")
+
+	allocs := testing.AllocsPerRun(100, func() {
+		s.Contains(ip4)
+		s.Contains(ip6)
+		s.ContainsAddr(addr)
+	})
+	assert.Zero(t, allocs)
+}
+
+// benchmarkFeed returns n ranges like in threat feeds: single addresses and small networks.
+func benchmarkFeed(n int) iprange.Ranges {
+	rnd := rand.New(rand.NewSource(1))
+	rr := make(iprange.Ranges, 0, n)
+	for i := 0; i < n; i++ {
+		ip := net.IPv4(byte(rnd.Intn(224)), byte(rnd.Intn(256)), byte(rnd.Intn(256)), byte(rnd.Intn(256)))
+		switch i % 3 {
+		case 0:
+			rr = append(rr, iprange.Parse(ip.String()))
+		case 1:
+			rr = append(rr, iprange.Parse(ip.String()+"/24"))
+		default:
+			rr = append(rr, iprange.Parse(fmt.Sprintf("%d.%d.1-10,20.0-255", ip[12], ip[13])))
+		}
+	}
+	return rr
+}
+
+// benchmarkAddrs returns random IPv4 addresses.
+func benchmarkAddrs(n int) []net.IP {
+	rnd := rand.New(rand.NewSource(2))
+	ips := make([]net.IP, n)
+	for i := range ips {
+		ips[i] = net.IPv4(byte(rnd.Intn(224)), byte(rnd.Intn(256)), byte(rnd.Intn(256)), byte(rnd.Intn(256)))
+	}
+	return ips
+}
+
+func BenchmarkIPSetContains(b *testing.B) {
+	ips := benchmarkAddrs(1024)
+
+	for _, n := range []int{100, 50000} {
+		rr := benchmarkFeed(n)
+		s := iprange.NewIPSet(rr)
+
+		b.Run(fmt.Sprintf("IPSet/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				s.Contains(ips[i%len(ips)])
+			}
+		})
+
+		b.Run(fmt.Sprintf("Ranges/%d", n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				rr.Contains(ips[i%len(ips)])
+			}
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package iprange
 
 import (
+	"bytes"
 	"math/big"
 	"net"
+	"net/netip"
 )
 
 // Iterator is an interface to iterate IP addresses
@@ -22,6 +24,66 @@ type Iterator interface {
 	Contains(net.IP) bool
 }
 
+// ReuseIterator is implemented by iterators which can save addresses
+// without allocating them. Iterators of the package implement it.
+type ReuseIterator interface {
+	Iterator
+
+	// NextInto is like Next, but reuses memory of the given IP if it has enough capacity,
+	// so the saved address is valid only until the next call.
+	NextInto(*net.IP) bool
+}
+
+var (
+	_ ReuseIterator = &singleIterator{}
+	_ ReuseIterator = &minMaxIterator{}
+	_ ReuseIterator = &octetsIterator{}
+	_ ReuseIterator = &rangesIterator{}
+	_ ReuseIterator = &randomIterator{}
+)
+
+// NextInto is like Next of the iterator, but reuses memory of the given IP if it has enough capacity.
+// Iterators which do not implement ReuseIterator allocate the address with Next.
+func NextInto(it Iterator, out *net.IP) bool {
+	if r, ok := it.(ReuseIterator); ok {
+		return r.NextInto(out)
+	}
+	return it.Next(out)
+}
+
+// BatchIterator is implemented by iterators which can save multiple addresses at once.
+// Iterators of the package implement it.
+type BatchIterator interface {
+	Iterator
+
+	// NextN saves up to len(dst) next IP-addresses into dst and returns their number.
+	// It returns less than len(dst) only if no addresses left.
+	NextN(dst []netip.Addr) int
+}
+
+var (
+	_ BatchIterator = &singleIterator{}
+	_ BatchIterator = &minMaxIterator{}
+	_ BatchIterator = &octetsIterator{}
+	_ BatchIterator = &rangesIterator{}
+	_ BatchIterator = &randomIterator{}
+)
+
+// NextN saves up to len(dst) next IP-addresses of the iterator into dst and returns their number.
+// It returns less than len(dst) only if no addresses left.
+// Iterators which do not implement BatchIterator are advanced with NextAddr.
+func NextN(it Iterator, dst []netip.Addr) int {
+	if b, ok := it.(BatchIterator); ok {
+		return b.NextN(dst)
+	}
+
+	n := 0
+	for n < len(dst) && NextAddr(it, &dst[n]) {
+		n++
+	}
+	return n
+}
+
 //
 // Single IP iterator.
 //
@@ -47,6 +109,38 @@ func (it *singleIterator) Next(out *net.IP) bool {
 	return true
 }
 
+func (it *singleIterator) NextInto(out *net.IP) bool {
+	if it.done {
+		return false
+	}
+
+	*out = reuseIP(*out, len(it.IP))
+	copy(*out, it.IP)
+
+	it.done = true
+
+	return true
+}
+
+func (it *singleIterator) NextAddr(out *netip.Addr) bool {
+	if it.done {
+		return false
+	}
+
+	*out, _ = netip.AddrFromSlice(it.IP)
+
+	it.done = true
+
+	return true
+}
+
+func (it *singleIterator) NextN(dst []netip.Addr) int {
+	if len(dst) == 0 || !it.NextAddr(&dst[0]) {
+		return 0
+	}
+	return 1
+}
+
 func (it *singleIterator) Reset() {
 	it.done = false
 }
@@ -57,28 +151,118 @@ func (it *singleIterator) Reset() {
 
 type minMaxIterator struct {
 	minMaxRange
-	current *big.Int
-	last    *big.Int
+	current uint128
+	first   uint128 // first address in the iteration order
+	last    uint128 // last address in the iteration order
+	done    bool
+	reverse bool
 }
 
 var _ Iterator = &minMaxIterator{}
 
+func newMinMaxIterator(r minMaxRange, reverse bool) *minMaxIterator {
+	it := &minMaxIterator{minMaxRange: r, first: u128FromIP(r.min), last: u128FromIP(r.max), reverse: reverse}
+	if reverse {
+		it.first, it.last = it.last, it.first
+	}
+	it.Reset()
+	return it
+}
+
 func (it *minMaxIterator) Next(out *net.IP) bool {
-	if it.current.Cmp(it.last) == 1 {
+	if it.done {
 		return false
 	}
 
 	ip := make(net.IP, len(it.min))
-	copy(ip, it.current.Bytes())
+	it.current.putIP(ip)
 	*out = ip
 
-	it.current.Add(it.current, big.NewInt(1))
+	it.step()
+
+	return true
+}
+
+func (it *minMaxIterator) NextInto(out *net.IP) bool {
+	if it.done {
+		return false
+	}
+
+	*out = reuseIP(*out, len(it.min))
+	it.current.putIP(*out)
+
+	it.step()
 
 	return true
 }
 
+func (it *minMaxIterator) NextAddr(out *netip.Addr) bool {
+	if it.done {
+		return false
+	}
+
+	*out = it.current.addr(len(it.min))
+
+	it.step()
+
+	return true
+}
+
+func (it *minMaxIterator) NextN(dst []netip.Addr) int {
+	if it.done {
+		return 0
+	}
+
+	// Number of addresses left minus one.
+	left := it.last.sub(it.current)
+	if it.reverse {
+		left = it.current.sub(it.last)
+	}
+
+	n := len(dst)
+	if left.hi == 0 && left.lo < uint64(n) {
+		n = int(left.lo) + 1
+		it.done = true
+	}
+
+	addr := it.current.addr(len(it.min))
+	for i := 0; i < n; i++ {
+		dst[i] = addr
+		if it.reverse {
+			addr = addr.Prev()
+		} else {
+			addr = addr.Next()
+		}
+	}
+
+	if !it.done {
+		if it.reverse {
+			it.current = it.current.sub64(uint64(n))
+		} else {
+			it.current = it.current.add64(uint64(n))
+		}
+	}
+
+	return n
+}
+
+// step moves the iterator to the next address.
+func (it *minMaxIterator) step() {
+	if it.current == it.last {
+		it.done = true
+		return
+	}
+
+	if it.reverse {
+		it.current = it.current.sub64(1)
+	} else {
+		it.current = it.current.add64(1)
+	}
+}
+
 func (it *minMaxIterator) Reset() {
-	it.current.SetBytes(it.min)
+	it.current = it.first
+	it.done = bytes.Compare(it.min, it.max) == 1
 }
 
 //
@@ -90,36 +274,117 @@ type octetsIterator struct {
 	done    bool
 	indexes []int
 	current []uint16
+	last    []uint16 // last address in the iteration order
+	reverse bool
 }
 
 var _ Iterator = &octetsIterator{}
 
+func newOctetsIterator(r octetsRange, reverse bool) *octetsIterator {
+	it := &octetsIterator{
+		octetsRange: r,
+		indexes:     make([]int, len(r.octets)),
+		current:     make([]uint16, len(r.octets)),
+		last:        r.octets.max(),
+		reverse:     reverse,
+	}
+	if reverse {
+		it.last = r.octets.min()
+	}
+	it.Reset()
+	return it
+}
+
 func (it *octetsIterator) Next(out *net.IP) bool {
 	if it.done {
 		return false
 	}
 
-	ip := octets2ip(it.current)
-	*out = ip
+	*out = octets2ip(it.current)
+	it.step()
+
+	return true
+}
+
+func (it *octetsIterator) NextInto(out *net.IP) bool {
+	if it.done {
+		return false
+	}
+
+	*out = reuseIP(*out, octets2iplen(it.current))
+	putOctets(*out, it.current)
+	it.step()
+
+	return true
+}
 
-	if octcmp(it.current, it.octets.max()) == 0 {
+func (it *octetsIterator) NextAddr(out *netip.Addr) bool {
+	if it.done {
+		return false
+	}
+
+	*out = octets2addr(it.current)
+	it.step()
+
+	return true
+}
+
+func (it *octetsIterator) NextN(dst []netip.Addr) int {
+	k := len(it.octets) - 1
+
+	n := 0
+	for n < len(dst) && !it.done {
+		// Addresses up to the end of the bound of the last octet differ only in the last octet.
+		b := it.octets[k][it.indexes[k]]
+		run := int(b.hi - it.current[k])
+		if it.reverse {
+			run = int(it.current[k] - b.lo)
+		}
+		if run >= len(dst)-n {
+			run = len(dst) - n - 1
+		}
+
+		addr := octets2addr(it.current)
+		for i := 0; i <= run; i++ {
+			dst[n] = addr
+			n++
+			if it.reverse {
+				addr = addr.Prev()
+			} else {
+				addr = addr.Next()
+			}
+		}
+
+		if it.reverse {
+			it.current[k] -= uint16(run)
+		} else {
+			it.current[k] += uint16(run)
+		}
+		it.step()
+	}
+
+	return n
+}
+
+// step moves the iterator to the next address.
+func (it *octetsIterator) step() {
+	if octcmp(it.current, it.last) == 0 {
 		it.done = true
-		return true
+		return
 	}
 
-	var (
-		oct uint16
-		j   int
-	)
+	if it.reverse {
+		it.stepBack()
+		return
+	}
 
-	for i := len(it.octets) - 1; i >= 0; i-- {
-		oct = it.current[i]
-		oct++
+	var j int
 
+	for i := len(it.octets) - 1; i >= 0; i-- {
 		j = it.indexes[i]
 
-		if oct >= it.octets[i][j].lo && oct <= it.octets[i][j].hi {
-			it.current[i] = oct
+		if it.current[i] < it.octets[i][j].hi {
+			it.current[i]++
 			break
 		} else if j+1 < len(it.octets[i]) {
 			it.current[i] = it.octets[i][j+1].lo
@@ -130,15 +395,41 @@ func (it *octetsIterator) Next(out *net.IP) bool {
 		it.current[i] = it.octets[i][0].lo
 		it.indexes[i] = 0
 	}
+}
 
-	return true
+// stepBack moves the iterator to the previous address,
+// bounds of every octet are traversed from the last one.
+func (it *octetsIterator) stepBack() {
+	var j int
+
+	for i := len(it.octets) - 1; i >= 0; i-- {
+		j = it.indexes[i]
+
+		if it.current[i] > it.octets[i][j].lo {
+			it.current[i]--
+			break
+		} else if j > 0 {
+			it.current[i] = it.octets[i][j-1].hi
+			it.indexes[i] = j - 1
+			break
+		}
+
+		j = len(it.octets[i]) - 1
+		it.current[i] = it.octets[i][j].hi
+		it.indexes[i] = j
+	}
 }
 
 func (it *octetsIterator) Reset() {
 	it.done = false
 	for i := 0; i < len(it.octets); i++ {
-		it.indexes[i] = 0
-		it.current[i] = it.octets[i][0].lo
+		if it.reverse {
+			it.indexes[i] = len(it.octets[i]) - 1
+			it.current[i] = it.octets[i][it.indexes[i]].hi
+		} else {
+			it.indexes[i] = 0
+			it.current[i] = it.octets[i][0].lo
+		}
 	}
 }
 
@@ -147,8 +438,9 @@ func (it *octetsIterator) Reset() {
 //
 
 type rangesIterator struct {
-	its []Iterator
-	idx int
+	its     []Iterator
+	idx     int
+	reverse bool
 }
 
 var _ Iterator = &rangesIterator{}
@@ -165,6 +457,42 @@ func (it *rangesIterator) Next(out *net.IP) bool {
 	return false
 }
 
+func (it *rangesIterator) NextInto(out *net.IP) bool {
+	for i := it.idx; i < len(it.its); i++ {
+		if NextInto(it.its[i], out) {
+			return true
+		}
+
+		it.idx++
+	}
+
+	return false
+}
+
+func (it *rangesIterator) NextAddr(out *netip.Addr) bool {
+	for i := it.idx; i < len(it.its); i++ {
+		if NextAddr(it.its[i], out) {
+			return true
+		}
+
+		it.idx++
+	}
+
+	return false
+}
+
+func (it *rangesIterator) NextN(dst []netip.Addr) int {
+	n := 0
+	for ; it.idx < len(it.its); it.idx++ {
+		n += NextN(it.its[it.idx], dst[n:])
+		if n == len(dst) {
+			break
+		}
+	}
+
+	return n
+}
+
 func (it *rangesIterator) Reset() {
 	it.idx = 0
 	for _, i := range it.its {
@@ -190,3 +518,12 @@ func (it *rangesIterator) Contains(ip net.IP) bool {
 	}
 	return false
 }
+
+func (it *rangesIterator) ContainsAddr(addr netip.Addr) bool {
+	for _, i := range it.its {
+		if containsAddr(i, addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,9 @@ package iprange_test
 
 import (
 	"fmt"
+	"math/big"
 	"net"
+	"net/netip"
 	"strings"
 	"testing"
 
@@ -65,6 +67,16 @@ func TestIterator(t *testing.T) {
 			false,
 		},
 
+		{
+			[]string{"0.0.0.5_0.0.0.6"},
+			[]string{
+				"0.0.0.5",
+				"0.0.0.6",
+			},
+			"0.0.0.7",
+			false,
+		},
+
 		// Octet
 		{
 			[]string{"104.16.99-100.10,52-53"},
@@ -120,6 +132,17 @@ func TestIterator(t *testing.T) {
 			false,
 		},
 
+		{
+			[]string{"::1_::3"},
+			[]string{
+				"::1",
+				"::2",
+				"::3",
+			},
+			"::",
+			false,
+		},
+
 		// octets
 		{
 			[]string{"2001:db8:9,10-12::"},
@@ -166,6 +189,7 @@ func TestIterator(t *testing.T) {
 
 			// Check contains.
 			assert.EqualValues(t, tt.contains, it.Contains(net.ParseIP(tt.ip)))
+			assert.EqualValues(t, tt.contains, it.(iprange.AddrContainer).ContainsAddr(netip.MustParseAddr(tt.ip)))
 
 			// Get results.
 			res := make([]string, 0)
@@ -183,6 +207,254 @@ func TestIterator(t *testing.T) {
 				res = append(res, ip.String())
 			}
 			assert.Equal(t, tt.res, res, "after reset")
+
+			// Reset and get results as netip.Addr.
+			it.Reset()
+			res = make([]string, 0)
+			var addr netip.Addr
+			for iprange.NextAddr(it, &addr) {
+				res = append(res, addr.String())
+			}
+			assert.Equal(t, tt.res, res, "addr")
+
+			// Reset and get results into buffer.
+			it.Reset()
+			res = make([]string, 0)
+			buf := make(net.IP, 0, net.IPv6len)
+			for iprange.NextInto(it, &buf) {
+				res = append(res, buf.String())
+			}
+			assert.Equal(t, tt.res, res, "into")
+		})
+	}
+}
+
+func TestIteratorFullGroup(t *testing.T) {
+	it := iprange.Parse("2001:db8::1-2:0-ffff").Iterator()
+
+	var (
+		ip net.IP
+		n  int
+	)
+	for it.Next(&ip) {
+		n++
+	}
+	assert.Equal(t, 2*65536, n)
+	assert.Equal(t, "2001:db8::2:ffff", ip.String())
+}
+
+func TestIteratorNextN(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.250_10.0.1.5"},
+		{"10.0.1-3,7.1-3,20-22"},
+		{"2001:db8::1-2:fffe-ffff,1-2"},
+		{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/126"},
+		{},
+	}
+
+	iterators := []struct {
+		name string
+		new  func(r iprange.Range) iprange.Iterator
+	}{
+		{"sequential", func(r iprange.Range) iprange.Iterator { return r.Iterator() }},
+		{"random", func(r iprange.Range) iprange.Iterator { return iprange.RandomIterator(r, 7) }},
+		{"reverse", iprange.ReverseIterator},
+	}
+
+	for _, tt := range tests {
+		for _, itt := range iterators {
+			for _, size := range []int{1, 3, 8, 100} {
+				t.Run(fmt.Sprintf("%s/%s/%d", itt.name, strings.Join(tt, ","), size), func(t *testing.T) {
+					rr := parseRanges(t, tt...)
+					expected := collect(itt.new(rr))
+
+					it := itt.new(rr)
+					dst := make([]netip.Addr, size)
+					res := make([]string, 0)
+					for {
+						n := iprange.NextN(it, dst)
+						for _, addr := range dst[:n] {
+							res = append(res, addr.String())
+						}
+						if n < size {
+							break
+						}
+					}
+
+					assert.Equal(t, expected, res)
+					assert.Zero(t, iprange.NextN(it, dst), "exhausted")
+					assert.Zero(t, iprange.NextN(it, nil), "empty dst")
+				})
+			}
+		}
+	}
+}
+
+var benchmarkRanges = []string{
+	"192.168.1.1",
+	"10.0.0.0/16",
+	"10.0.0-255.0-255",
+	"2001:db8::/112",
+}
+
+func TestIteratorOutside(t *testing.T) {
+	r := plainRange{iprange.Parse("10.0.0.1-3")}
+	rr := iprange.Ranges{r, iprange.Parse("10.0.1.1")}
+	expected := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.1.1"}
+
+	assert.True(t, iprange.ContainsAddr(r, netip.MustParseAddr("10.0.0.2")))
+	assert.True(t, iprange.ContainsAddr(r, netip.MustParseAddr("::ffff:10.0.0.2")))
+	assert.False(t, iprange.ContainsAddr(r, netip.MustParseAddr("10.0.0.4")))
+	assert.False(t, iprange.ContainsAddr(r, netip.Addr{}))
+	assert.True(t, rr.ContainsAddr(netip.MustParseAddr("10.0.0.1")))
+	assert.True(t, rr.Iterator().(iprange.AddrContainer).ContainsAddr(netip.MustParseAddr("10.0.0.1")))
+
+	for _, tt := range []struct {
+		it  iprange.Iterator
+		res []string
+	}{
+		{r.Iterator(), expected[:3]},
+		{rr.Iterator(), expected},
+	} {
+		it := tt.it
+		res := make([]string, 0)
+		var addr netip.Addr
+		for iprange.NextAddr(it, &addr) {
+			require.True(t, addr.Is4(), addr.String())
+			res = append(res, addr.String())
+		}
+		assert.Equal(t, tt.res, res, "addr")
+
+		it.Reset()
+		res = make([]string, 0)
+		buf := make(net.IP, 0, net.IPv6len)
+		for iprange.NextInto(it, &buf) {
+			res = append(res, buf.String())
+		}
+		assert.Equal(t, tt.res, res, "into")
+
+		it.Reset()
+		dst := make([]netip.Addr, 2)
+		require.Equal(t, 2, iprange.NextN(it, dst))
+		assert.Equal(t, "10.0.0.2", dst[1].String(), "batch")
+
+		it.Reset()
+		iprange.Skip(it, big.NewInt(2))
+		require.True(t, iprange.NextAddr(it, &addr))
+		assert.Equal(t, "10.0.0.3", addr.String(), "skip")
+	}
+
+	it := r.Iterator()
+	assert.False(t, iprange.SeekTo(it, net.ParseIP("10.0.0.2")))
+	assert.Nil(t, iprange.Checkpoint(it))
+
+	// Ranges with members implemented outside of the package can not seek in them.
+	it = rr.Iterator()
+	assert.False(t, iprange.SeekTo(it, net.ParseIP("10.0.0.2")))
+	assert.True(t, iprange.SeekTo(it, net.ParseIP("10.0.1.1")))
+
+	resumed, err := iprange.Resume(rr, iprange.Checkpoint(rr.Iterator()))
+	require.NoError(t, err)
+	assert.Equal(t, expected, collect(resumed))
+}
+
+// plainRange is a range implemented outside of the package,
+// it has only methods of Range and its iterator has only methods of Iterator.
+type plainRange struct {
+	r iprange.Range
+}
+
+var _ iprange.Range = plainRange{}
+
+func (p plainRange) Contains(ip net.IP) bool    { return p.r.Contains(ip) }
+func (p plainRange) Count() *big.Int            { return p.r.Count() }
+func (p plainRange) Iterator() iprange.Iterator { return plainIterator{p.r.Iterator()} }
+
+// plainIterator saves IPv4 addresses in 16 bytes like net.ParseIP does.
+type plainIterator struct {
+	it iprange.Iterator
+}
+
+var _ iprange.Iterator = plainIterator{}
+
+func (p plainIterator) Next(ip *net.IP) bool {
+	if !p.it.Next(ip) {
+		return false
+	}
+	*ip = ip.To16()
+	return true
+}
+
+func (p plainIterator) Reset()                  { p.it.Reset() }
+func (p plainIterator) Count() *big.Int         { return p.it.Count() }
+func (p plainIterator) Contains(ip net.IP) bool { return p.it.Contains(ip) }
+
+func BenchmarkIteratorNext(b *testing.B) {
+	for _, s := range benchmarkRanges {
+		r := iprange.Parse(s)
+		b.Run(s, func(b *testing.B) {
+			b.ReportAllocs()
+			it := r.Iterator()
+			var ip net.IP
+			for i := 0; i < b.N; i++ {
+				if !it.Next(&ip) {
+					it.Reset()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIteratorNextInto(b *testing.B) {
+	for _, s := range append(benchmarkRanges, strings.Join(benchmarkRanges, " ")) {
+		rr := make(iprange.Ranges, 0)
+		for _, f := range strings.Fields(s) {
+			rr = append(rr, iprange.Parse(f))
+		}
+		b.Run(s, func(b *testing.B) {
+			b.ReportAllocs()
+			it := rr.Iterator().(iprange.ReuseIterator)
+			ip := make(net.IP, 0, net.IPv6len)
+			for i := 0; i < b.N; i++ {
+				if !it.NextInto(&ip) {
+					it.Reset()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIteratorNextAddr(b *testing.B) {
+	for _, s := range benchmarkRanges {
+		r := iprange.Parse(s)
+		b.Run(s, func(b *testing.B) {
+			b.ReportAllocs()
+			it := r.Iterator().(iprange.AddrIterator)
+			var addr netip.Addr
+			for i := 0; i < b.N; i++ {
+				if !it.NextAddr(&addr) {
+					it.Reset()
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkIteratorNextN(b *testing.B) {
+	for _, s := range benchmarkRanges {
+		r := iprange.Parse(s)
+		b.Run(s, func(b *testing.B) {
+			b.ReportAllocs()
+			it := r.Iterator().(iprange.BatchIterator)
+			dst := make([]netip.Addr, 4096)
+			for i := 0; i < b.N; {
+				n := it.NextN(dst)
+				if n < len(dst) {
+					it.Reset()
+				}
+				i += n
+			}
 		})
 	}
 }
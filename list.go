@@ -0,0 +1,346 @@
+package iprange
+
+import (
+	"bufio"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// List is a list of IP-addresses ranges which can be used in text, JSON and YAML
+// encoded configs and as a command-line flag.
+type List struct {
+	Ranges
+
+	// Exclusions of all Set calls, they apply to ranges of later calls too.
+	excluded Ranges
+}
+
+var (
+	_ encoding.TextMarshaler   = List{}
+	_ encoding.TextUnmarshaler = &List{}
+	_ json.Marshaler           = List{}
+	_ json.Unmarshaler         = &List{}
+	_ flag.Value               = &List{}
+)
+
+// MarshalText encodes ranges separated by spaces.
+func (l List) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText decodes ranges in the format of ParseList.
+func (l *List) UnmarshalText(text []byte) error {
+	rr, err := ParseList(string(text))
+	if err != nil {
+		return err
+	}
+	l.Ranges, l.excluded = rr, nil
+	return nil
+}
+
+// MarshalJSON encodes ranges as an array of strings.
+func (l List) MarshalJSON() ([]byte, error) {
+	ss := make([]string, len(l.Ranges))
+	for i, r := range l.Ranges {
+		ss[i] = fmt.Sprint(r)
+	}
+	return json.Marshal(ss)
+}
+
+// UnmarshalJSON decodes ranges from a string or from an array of strings
+// in the format of ParseList. Exclusions apply to ranges of all elements of the array.
+// Line of a *ListError is the number of the element, starting from 1.
+func (l *List) UnmarshalJSON(data []byte) error {
+	var ss []string
+	if err := json.Unmarshal(data, &ss); err != nil {
+		var s string
+		if json.Unmarshal(data, &s) != nil {
+			return err
+		}
+		return l.UnmarshalText([]byte(s))
+	}
+
+	return l.unmarshalStrings(ss)
+}
+
+// UnmarshalYAML decodes ranges from a YAML scalar or from a sequence of strings like UnmarshalJSON.
+// It implements the Unmarshaler interface of gopkg.in/yaml.v2, which gopkg.in/yaml.v3 supports too,
+// so the package does not depend on them.
+func (l *List) UnmarshalYAML(unmarshal func(any) error) error {
+	var ss []string
+	if err := unmarshal(&ss); err != nil {
+		var s string
+		if unmarshal(&s) != nil {
+			return err
+		}
+		return l.UnmarshalText([]byte(s))
+	}
+
+	return l.unmarshalStrings(ss)
+}
+
+// unmarshalStrings decodes ranges from elements of an array in the format of ParseList.
+func (l *List) unmarshalStrings(ss []string) error {
+	p := &listParser{opts: newParseOptions(nil)}
+	for i, s := range ss {
+		p.parseLine(s, i+1)
+		if len(p.errs) > 0 {
+			return p.errs[0]
+		}
+	}
+
+	l.Ranges, l.excluded = p.result(), nil
+	return nil
+}
+
+// Set appends ranges in the format of ParseList, so the flag can be repeated.
+// Exclusions apply to ranges of all occurrences of the flag, wherever they are.
+func (l *List) Set(s string) error {
+	p := &listParser{
+		opts:     newParseOptions(nil),
+		ranges:   l.Ranges[:len(l.Ranges):len(l.Ranges)],
+		excluded: l.excluded[:len(l.excluded):len(l.excluded)],
+	}
+
+	for i, line := range strings.Split(s, "\n") {
+		p.parseLine(line, i+1)
+		if len(p.errs) > 0 {
+			return p.errs[0]
+		}
+	}
+
+	l.Ranges, l.excluded = p.result(), p.excluded
+	return nil
+}
+
+// ListError describes a problem parsing an entry of a list of ranges.
+type ListError struct {
+	// Line is the number of the line with the entry, starting from 1.
+	Line int
+
+	// Column is the byte position in the line where parsing failed, starting from 1.
+	Column int
+
+	// Err describes the problem with the entry.
+	Err *ParseError
+}
+
+func (e *ListError) Error() string {
+	return fmt.Sprintf("iprange: line %d, column %d: invalid range %q: %s", e.Line, e.Column, e.Err.Input, e.Err.Reason)
+}
+
+func (e *ListError) Unwrap() error {
+	return e.Err
+}
+
+// ListErrors is a list of problems parsing a list of ranges, see AllErrors.
+type ListErrors []*ListError
+
+func (ee ListErrors) Error() string {
+	ss := make([]string, len(ee))
+	for i, e := range ee {
+		ss[i] = e.Error()
+	}
+	return strings.Join(ss, "\n")
+}
+
+// ParseList parses s as a list of ranges in any of the formats Parse accepts.
+// Ranges are separated by whitespace, newlines or commas, whitespace around a comma is a part
+// of the separator, but entries between commas can not be empty. Text from '#' to the end
+// of the line is a comment. Comma inside an entry is treated as a separator of octet values if the entry
+// is a valid octets range with it, e.g. "10.0.0.1,2" is one range, "10.0.0.1,10.0.0.2" are two.
+// Entries prefixed with '!' are exclusions, e.g. "10.0.0.0/16 !10.0.5.0/24":
+// their addresses are subtracted from all other ranges of the list, wherever they are.
+// If an entry is invalid, ParseList returns nil and a *ListError with its position,
+// with AllErrors option it returns valid ranges and ListErrors with all invalid entries.
+// With NmapCompatible option the list is parsed like nmap -iL target files.
+// Addresses with ACL wildcard masks are not supported, whitespace separates the mask.
+func ParseList(s string, opts ...ParseOption) (Ranges, error) {
+	return ParseReader(strings.NewReader(s), opts...)
+}
+
+// ParseReader is like ParseList, but reads the list from r.
+func ParseReader(r io.Reader, opts ...ParseOption) (Ranges, error) {
+	p := &listParser{opts: newParseOptions(opts)}
+	br := bufio.NewReader(r)
+
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		p.parseLine(line, n)
+		if len(p.errs) > 0 && !p.opts.allErrors {
+			return nil, p.errs[0]
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if len(p.errs) > 0 {
+		return p.result(), p.errs
+	}
+
+	return p.result(), nil
+}
+
+// listParser accumulates ranges and problems of a list.
+type listParser struct {
+	opts     *parseOptions
+	ranges   Ranges
+	excluded Ranges
+	errs     ListErrors
+}
+
+// result returns parsed ranges without excluded addresses.
+func (p *listParser) result() Ranges {
+	if len(p.excluded) == 0 {
+		if p.ranges == nil {
+			return Ranges{}
+		}
+		return p.ranges
+	}
+
+	// Index of excluded addresses is built once for all ranges.
+	excluded := newBoxIndexes(disjoint(p.excluded.boxes()))
+
+	rr := make(Ranges, 0, len(p.ranges))
+	for _, r := range p.ranges {
+		switch r := fromBoxes(subtractIndexed(disjoint(boxesOf(r)), excluded)).(type) {
+		case Ranges:
+			rr = append(rr, r...)
+		default:
+			rr = append(rr, r)
+		}
+	}
+
+	return rr
+}
+
+// parseLine parses the line with number n.
+func (p *listParser) parseLine(line string, n int) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+
+	// Comma can separate the previous entry from the field, e.g. "10.0.0.1 , 10.0.0.2".
+	sep := false
+
+	for col := 0; col < len(line); {
+		if isSpace(line[col]) {
+			col++
+			continue
+		}
+
+		end := col
+		for end < len(line) && !isSpace(line[end]) {
+			end++
+		}
+
+		if sep && line[col] == ',' && !p.opts.nmap {
+			col++
+		}
+		sep = col < end && line[end-1] != ','
+
+		if off, perr := p.parseField(line[col:end]); perr != nil {
+			p.errs = append(p.errs, &ListError{n, col + off + perr.Offset + 1, perr})
+			if !p.opts.allErrors {
+				return
+			}
+		}
+
+		col = end
+	}
+}
+
+// parseField parses whitespace-separated field.
+// Returns offset of the invalid entry in the field and its problem if there is one.
+// Entry is extended by commas from the shortest candidate while it stays valid
+// or needs more octets, the longest valid candidate is taken.
+// Comma can end the field, but entries between commas can not be empty.
+func (p *listParser) parseField(field string) (int, *ParseError) {
+	// nmap target specifications are separated only by whitespace.
+	if p.opts.nmap {
+		r, err := parseRange(field, p.opts)
+		if err != nil {
+			return 0, err.(*ParseError)
+		}
+		p.ranges = append(p.ranges, r)
+		return 0, nil
+	}
+
+	for pos := 0; pos < len(field); {
+		if field[pos] == ',' {
+			return pos, &ParseError{"", 0, reasonEmptyEntry}
+		}
+
+		excluded := field[pos] == '!'
+		if excluded {
+			pos++
+		}
+
+		if pos == len(field) || field[pos] == ',' {
+			return pos, &ParseError{"", 0, reasonNotIP}
+		}
+
+		var (
+			r    Range
+			end  = -1
+			perr *ParseError
+		)
+
+		for e := pos; e < len(field); {
+			// Candidate ends at the next comma or at the end of the field.
+			if i := strings.IndexByte(field[e+1:], ','); i >= 0 {
+				e += 1 + i
+			} else {
+				e = len(field)
+			}
+
+			c, err := parseRange(field[pos:e], p.opts)
+			if err == nil {
+				r, end = c, e
+				continue
+			}
+
+			// Report the problem of the candidate which was parsed further.
+			cerr := err.(*ParseError)
+			if perr == nil || cerr.Offset > perr.Offset {
+				perr = cerr
+			}
+
+			// The problem is not a lack of octets, longer candidates have it too.
+			if cerr.Offset < e-pos {
+				break
+			}
+		}
+
+		if end < 0 {
+			return pos, perr
+		}
+
+		if excluded {
+			p.excluded = append(p.excluded, r)
+		} else {
+			p.ranges = append(p.ranges, r)
+		}
+
+		// Skip the comma after the entry.
+		pos = end + 1
+	}
+
+	return 0, nil
+}
+
+// isSpace reports whether c is a whitespace separator of list entries.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\v' || c == '\f'
+}
@@ -0,0 +1,389 @@
+package iprange_test
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/russtone/iprange"
+)
+
+func TestListJSON(t *testing.T) {
+	tests := []struct {
+		json  string
+		count int64
+		res   string
+	}{
+		{`"10.0.0.0/24"`, 256, `["10.0.0.0/24"]`},
+		{`["10.0.0.0/24", "10.0.1.1_10.0.1.10", "2001:db8::1-2"]`, 268, `["10.0.0.0/24","10.0.1.1_10.0.1.10","2001:db8::1-2"]`},
+		{`[]`, 0, `[]`},
+		{`"10.0.0.0/24 !10.0.0.1"`, 255, `["10.0.0.0,2-255"]`},
+		{`["10.0.0.0/24", "!10.0.0.1", "10.0.1.0/30"]`, 259, `["10.0.0.0,2-255","10.0.1.0/30"]`},
+		{`["!10.0.0.0/24", "10.0.0.0/23"]`, 256, `["10.0.1.0/24"]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			var cfg struct {
+				Targets iprange.List `json:"targets"`
+			}
+
+			err := json.Unmarshal([]byte(`{"targets": `+tt.json+`}`), &cfg)
+			require.NoError(t, err)
+			assert.EqualValues(t, tt.count, cfg.Targets.Count().Int64())
+
+			data, err := json.Marshal(cfg)
+			require.NoError(t, err)
+			assert.Equal(t, `{"targets":`+tt.res+`}`, string(data))
+		})
+	}
+}
+
+func TestListJSONInvalid(t *testing.T) {
+	tests := []string{
+		`"10.0.0.300"`,
+		`["10.0.0.0/24", "invalid"]`,
+		`10`,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt, func(t *testing.T) {
+			var l iprange.List
+			assert.Error(t, json.Unmarshal([]byte(tt), &l))
+		})
+	}
+}
+
+func TestListYAML(t *testing.T) {
+	tests := []struct {
+		yaml  string
+		count int64
+		res   string
+	}{
+		{"10.0.0.0/24", 256, "10.0.0.0/24"},
+		{"10.0.0.0/24 10.0.1.1", 257, "10.0.0.0/24 10.0.1.1"},
+		{"[10.0.0.0/24, 10.0.1.1]", 257, "10.0.0.0/24 10.0.1.1"},
+		{"\n  - 10.0.0.0/24\n  - '!10.0.0.1'\n  - 2001:db8::1", 256, "10.0.0.0,2-255 2001:db8::1"},
+		{"[]", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.yaml, func(t *testing.T) {
+			var cfg struct {
+				Targets iprange.List `yaml:"targets"`
+			}
+
+			err := yaml.Unmarshal([]byte("targets: "+tt.yaml), &cfg)
+			require.NoError(t, err)
+			assert.EqualValues(t, tt.count, cfg.Targets.Count().Int64())
+			assert.Equal(t, tt.res, cfg.Targets.String())
+		})
+	}
+
+	var cfg struct {
+		Targets iprange.List `yaml:"targets"`
+	}
+	assert.Error(t, yaml.Unmarshal([]byte("targets: [10.0.0.0/24, 10.0.0.300]"), &cfg))
+	assert.Error(t, yaml.Unmarshal([]byte("targets: {a: 1}"), &cfg))
+}
+
+func TestListJSONError(t *testing.T) {
+	var l iprange.List
+	err := json.Unmarshal([]byte(`["10.0.0.0/24", "!10.0.0.1", "10.0.1.300"]`), &l)
+
+	var lerr *iprange.ListError
+	require.True(t, errors.As(err, &lerr), err)
+	assert.Equal(t, 3, lerr.Line)
+	assert.Equal(t, 8, lerr.Column)
+}
+
+func TestListText(t *testing.T) {
+	var l iprange.List
+	require.NoError(t, l.UnmarshalText([]byte("10.0.0.0/24\n 2001:db8::1")))
+	assert.EqualValues(t, 257, l.Count().Int64())
+
+	text, err := l.MarshalText()
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.0/24 2001:db8::1", string(text))
+
+	err = l.UnmarshalText([]byte("10.0.0.0/24 10.0.0.1-2-3"))
+	var perr *iprange.ParseError
+	require.True(t, errors.As(err, &perr))
+	assert.Equal(t, "10.0.0.1-2-3", perr.Input)
+}
+
+func TestListFlag(t *testing.T) {
+	var l iprange.List
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&l, "target", "targets")
+
+	err := fs.Parse([]string{"-target", "10.0.0.1", "-target", "10.0.1.0/30 10.0.2.1"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, l.Count().Int64())
+	assert.True(t, l.Contains(net.ParseIP("10.0.1.3")))
+	assert.Equal(t, "10.0.0.1 10.0.1.0/30 10.0.2.1", l.String())
+
+	fs.SetOutput(ioutil.Discard)
+	assert.Error(t, fs.Parse([]string{"-target", "10.0.0"}))
+	assert.Equal(t, "10.0.0.1 10.0.1.0/30 10.0.2.1", l.String())
+}
+
+func TestListFlagExclusions(t *testing.T) {
+	var l iprange.List
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(&l, "target", "targets")
+
+	err := fs.Parse([]string{"-target", "10.0.0.0/30", "-target", "!10.0.0.1", "-target", "10.0.0.1-5,7"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0,2-3", "10.0.0.2-5,7"}, rangesStrings(l.Ranges))
+	assert.False(t, l.Contains(net.ParseIP("10.0.0.1")))
+}
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		s   string
+		res []string
+	}{
+		{"10.0.0.0/24, 10.0.1.5 10.2.0.1-10", []string{"10.0.0.0/24", "10.0.1.5", "10.2.0.1-10"}},
+		{"10.0.0.1,10.0.0.2,2001:db8::1", []string{"10.0.0.1", "10.0.0.2", "2001:db8::1"}},
+		{"10.0.0.1,2", []string{"10.0.0.1-2"}},
+		{"192.168.1,3,5.1-10,10.0.0.1", []string{"192.168.1,3,5.1-10", "10.0.0.1"}},
+		{"10.0.0.1,10.0.0.2,", []string{"10.0.0.1", "10.0.0.2"}},
+		{"1.2.3.4 ,5.6.7.8", []string{"1.2.3.4", "5.6.7.8"}},
+		{"1.2.3.4 , 5.6.7.8\t,\t10.0.0.1-2 ,2001:db8::1", []string{"1.2.3.4", "5.6.7.8", "10.0.0.1-2", "2001:db8::1"}},
+		{"1.2.3.4, 5.6.7.8 ,", []string{"1.2.3.4", "5.6.7.8"}},
+		{"10.0.0.1-2,3,10.0.0.5,6", []string{"10.0.0.1-3", "10.0.0.5-6"}},
+		{"# targets\n\n10.0.0.0/24 # office\r\n\t2001:db8::1\n", []string{"10.0.0.0/24", "2001:db8::1"}},
+		{"10.0.0.1_10.0.0.5\n10.0.1.1", []string{"10.0.0.1_10.0.0.5", "10.0.1.1"}},
+		{"10.0.0.1-10.0.0.5,10.0.1.1-10.0.1.9", []string{"10.0.0.1_10.0.0.5", "10.0.1.1_10.0.1.9"}},
+		{"", []string{}},
+		{"  # nothing\n", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			rr, err := iprange.ParseList(tt.s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.res, rangesStrings(rr))
+
+			rr, err = iprange.ParseReader(strings.NewReader(tt.s))
+			require.NoError(t, err)
+			assert.Equal(t, tt.res, rangesStrings(rr))
+		})
+	}
+}
+
+func TestParseListLongField(t *testing.T) {
+	ss := make([]string, 5000)
+	for i := range ss {
+		ss[i] = fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+	}
+
+	rr, err := iprange.ParseList(strings.Join(ss, ","))
+	require.NoError(t, err)
+	assert.Equal(t, ss, rangesStrings(rr))
+}
+
+func TestParseListError(t *testing.T) {
+	tests := []struct {
+		s      string
+		line   int
+		column int
+		input  string
+		reason string
+	}{
+		{"10.0.0.300", 1, 8, "10.0.0.300", "octet > 255"},
+		{"10.0.0.0/24, 10.0.1.1-2-3", 1, 24, "10.0.1.1-2-3", "too many dashes"},
+		{"10.0.0.1,10.0.0.300", 1, 17, "10.0.0.300", "octet > 255"},
+		{"# comment\n10.0.0.1\n  10.0.0", 3, 9, "10.0.0", "too few octets"},
+		{"10.0.0.1\n2001:db8::1_10.0.0.1", 2, 13, "2001:db8::1_10.0.0.1", "address family mismatch"},
+		{"10.0.0.1,,10.0.0.3", 1, 10, "", "empty entry"},
+		{",10.0.0.1", 1, 1, "", "empty entry"},
+		{"10.0.0.1 ,,10.0.0.3", 1, 11, "", "empty entry"},
+		{"10.0.0.1 , , 10.0.0.3", 1, 12, "", "empty entry"},
+		{"10.0.0.1, ,10.0.0.3", 1, 11, "", "empty entry"},
+		{"10.0.0.1\n,10.0.0.3", 2, 1, "", "empty entry"},
+		{"10.0.0.1 !", 1, 11, "", "not an IP address"},
+		{"10.0.0.1,!,10.0.0.2", 1, 11, "", "not an IP address"},
+		{"192.168.1,3,5.300", 1, 15, "192.168.1,3,5.300", "octet > 255"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			rr, err := iprange.ParseList(tt.s)
+			assert.Nil(t, rr)
+
+			var lerr *iprange.ListError
+			require.True(t, errors.As(err, &lerr), err)
+			assert.Equal(t, tt.line, lerr.Line)
+			assert.Equal(t, tt.column, lerr.Column)
+			assert.Equal(t, tt.input, lerr.Err.Input)
+			assert.Equal(t, tt.reason, lerr.Err.Reason)
+
+			var perr *iprange.ParseError
+			require.True(t, errors.As(err, &perr))
+		})
+	}
+}
+
+func TestParseListAllErrors(t *testing.T) {
+	s := "10.0.0.1 10.0.0.300\n10.0.1.0/24\n10.0.0 10.0.2.1, 10.0.3.1-2-3"
+
+	rr, err := iprange.ParseList(s, iprange.AllErrors())
+	assert.Equal(t, []string{"10.0.0.1", "10.0.1.0/24", "10.0.2.1"}, rangesStrings(rr))
+
+	var errs iprange.ListErrors
+	require.True(t, errors.As(err, &errs), err)
+	require.Len(t, errs, 3)
+
+	assert.Equal(t, `iprange: line 1, column 17: invalid range "10.0.0.300": octet > 255`, errs[0].Error())
+	assert.Equal(t, `iprange: line 3, column 7: invalid range "10.0.0": too few octets`, errs[1].Error())
+	assert.Equal(t, `iprange: line 3, column 28: invalid range "10.0.3.1-2-3": too many dashes`, errs[2].Error())
+
+	rr, err = iprange.ParseList("10.0.0.1", iprange.AllErrors())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1"}, rangesStrings(rr))
+}
+
+func TestParseListNmapCompatible(t *testing.T) {
+	rr, err := iprange.ParseList("10.0.0.1,2 192.168.*.1\n10.1.0-.1 # comment", iprange.NmapCompatible())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1-2", "192.168.0-255.1", "10.1.0-255.1"}, rangesStrings(rr))
+
+	_, err = iprange.ParseList("10.0.0.1,10.0.0.2", iprange.NmapCompatible())
+	var lerr *iprange.ListError
+	require.True(t, errors.As(err, &lerr), err)
+	assert.Equal(t, 1, lerr.Line)
+	assert.Equal(t, 13, lerr.Column)
+
+	_, err = iprange.ParseList("10.0.0.0/24 !10.0.0.1", iprange.NmapCompatible())
+	require.True(t, errors.As(err, &lerr), err)
+	assert.Equal(t, 13, lerr.Column)
+}
+
+func TestParseListExclusion(t *testing.T) {
+	tests := []struct {
+		s       string
+		res     string
+		count   int64
+		in, out []string
+	}{
+		{
+			"10.0.0.0/16 !10.0.5.0/24 !10.0.9.1",
+			"10.0.0-4,6-8,10-255.0-255 10.0.9.0,2-255",
+			65536 - 256 - 1,
+			[]string{"10.0.4.255", "10.0.9.0", "10.0.9.2"},
+			[]string{"10.0.5.0", "10.0.5.255", "10.0.9.1"},
+		},
+		{
+			"!10.0.0.1_10.0.0.3\n10.0.0.0/29, 10.0.1.1",
+			"10.0.0.0,4-7 10.0.1.1",
+			6,
+			[]string{"10.0.0.0", "10.0.0.4", "10.0.1.1"},
+			[]string{"10.0.0.1", "10.0.0.3"},
+		},
+		{
+			"10.0.1-3.1-10 !10.0.2.1-5,8",
+			"10.0.1,3.1-10 10.0.2.6-7,9-10",
+			24,
+			[]string{"10.0.1.1", "10.0.2.6", "10.0.2.9"},
+			[]string{"10.0.2.1", "10.0.2.8"},
+		},
+		{
+			"10.0.0.1,!10.0.0.1,10.0.0.2",
+			"10.0.0.2",
+			1,
+			[]string{"10.0.0.2"},
+			[]string{"10.0.0.1"},
+		},
+		{
+			"2001:db8::/120 !2001:db8::/121 10.0.0.1",
+			"2001:db8::80/121 10.0.0.1",
+			129,
+			[]string{"2001:db8::80", "10.0.0.1"},
+			[]string{"2001:db8::7f"},
+		},
+		{
+			"!10.0.0.1",
+			"",
+			0,
+			[]string{},
+			[]string{"10.0.0.1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			rr, err := iprange.ParseList(tt.s)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.res, rr.String())
+			assert.Equal(t, tt.count, rr.Count().Int64())
+			assert.Len(t, addresses(rr), int(tt.count))
+
+			for _, ip := range tt.in {
+				assert.True(t, rr.Contains(net.ParseIP(ip)), ip)
+			}
+			for _, ip := range tt.out {
+				assert.False(t, rr.Contains(net.ParseIP(ip)), ip)
+			}
+		})
+	}
+}
+
+func TestParseListExclusionLarge(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&sb, "10.%d.%d.0/24 !10.%d.%d.5\n", i/256, i%256, i/256, i%256)
+	}
+
+	rr, err := iprange.ParseList(sb.String())
+	require.NoError(t, err)
+	assert.Len(t, rr, 3000)
+	assert.Equal(t, big.NewInt(3000*255), rr.Count())
+	assert.True(t, rr.Contains(net.ParseIP("10.11.183.4")))
+	assert.False(t, rr.Contains(net.ParseIP("10.11.183.5")))
+}
+
+func TestParseListExclusionError(t *testing.T) {
+	_, err := iprange.ParseList("10.0.0.0/24 !10.0.0.300")
+
+	var lerr *iprange.ListError
+	require.True(t, errors.As(err, &lerr), err)
+	assert.Equal(t, 21, lerr.Column)
+	assert.Equal(t, "10.0.0.300", lerr.Err.Input)
+
+	_, err = iprange.ParseList("10.0.0.0/24 !")
+	require.True(t, errors.As(err, &lerr), err)
+	assert.Equal(t, 14, lerr.Column)
+	assert.Equal(t, "not an IP address", lerr.Err.Reason)
+}
+
+func TestParseReaderError(t *testing.T) {
+	errRead := errors.New("read error")
+
+	_, err := iprange.ParseReader(iotest.ErrReader(errRead))
+	assert.True(t, errors.Is(err, errRead), err)
+}
+
+// rangesStrings returns ranges as strings.
+func rangesStrings(rr iprange.Ranges) []string {
+	res := make([]string, len(rr))
+	for i, r := range rr {
+		res[i] = fmt.Sprint(r)
+	}
+	return res
+}
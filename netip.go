@@ -0,0 +1,110 @@
+package iprange
+
+import (
+	"net"
+	"net/netip"
+)
+
+// AddrContainer is implemented by ranges and iterators which can check netip.Addr
+// without converting it to net.IP. Ranges and iterators of the package implement it.
+type AddrContainer interface {
+	// ContainsAddr is like Contains, but takes netip.Addr.
+	ContainsAddr(netip.Addr) bool
+}
+
+// AddrIterator is implemented by iterators which can save addresses as netip.Addr
+// without allocating net.IP. Iterators of the package implement it.
+type AddrIterator interface {
+	Iterator
+
+	// NextAddr is like Next, but saves the address as netip.Addr.
+	NextAddr(*netip.Addr) bool
+}
+
+var (
+	_ AddrContainer = singleRange{}
+	_ AddrContainer = minMaxRange{}
+	_ AddrContainer = octetsRange{}
+	_ AddrContainer = Ranges{}
+	_ AddrContainer = &IPSet{}
+
+	_ AddrContainer = &singleIterator{}
+	_ AddrContainer = &minMaxIterator{}
+	_ AddrContainer = &octetsIterator{}
+	_ AddrContainer = &rangesIterator{}
+	_ AddrContainer = &randomIterator{}
+
+	_ AddrIterator = &singleIterator{}
+	_ AddrIterator = &minMaxIterator{}
+	_ AddrIterator = &octetsIterator{}
+	_ AddrIterator = &rangesIterator{}
+	_ AddrIterator = &randomIterator{}
+)
+
+// ContainsAddr checks if the address is in the range, IPv4-mapped IPv6 addresses are IPv4 ones.
+// Ranges which do not implement AddrContainer are checked with Contains.
+func ContainsAddr(r Range, addr netip.Addr) bool {
+	return containsAddr(r, addr)
+}
+
+// NextAddr is like Next of the iterator, but saves the address as netip.Addr.
+// Iterators which do not implement AddrIterator are advanced with Next.
+func NextAddr(it Iterator, out *netip.Addr) bool {
+	if a, ok := it.(AddrIterator); ok {
+		return a.NextAddr(out)
+	}
+
+	var ip net.IP
+	if !it.Next(&ip) {
+		return false
+	}
+
+	addr, _ := netip.AddrFromSlice(ip)
+	*out = addr.Unmap()
+
+	return true
+}
+
+// containsAddr is ContainsAddr of ranges and iterators.
+func containsAddr(c interface{ Contains(net.IP) bool }, addr netip.Addr) bool {
+	if a, ok := c.(AddrContainer); ok {
+		return a.ContainsAddr(addr)
+	}
+	return addr.IsValid() && c.Contains(addr.Unmap().AsSlice())
+}
+
+// FromPrefix returns range of addresses of the prefix.
+// IPv4-mapped IPv6 prefixes of at least 96 bits give IPv4 ranges.
+// Returns nil if the prefix is invalid.
+func FromPrefix(p netip.Prefix) Range {
+	if !p.IsValid() {
+		return nil
+	}
+
+	addr, bits := p.Addr(), p.Bits()
+	if addr.Is4In6() && bits >= 96 {
+		addr, bits = addr.Unmap(), bits-96
+	}
+
+	return cidr2range(addr.AsSlice(), bits, addr.BitLen())
+}
+
+// FromAddrs returns range of addresses between begin and end inclusive.
+// If both addresses are IPv4-mapped IPv6 addresses, the range is IPv4.
+// Returns nil if any of the addresses is invalid, they are from different
+// families or begin is greater than end.
+func FromAddrs(begin, end netip.Addr) Range {
+	if !begin.IsValid() || begin.BitLen() != end.BitLen() || end.Less(begin) {
+		return nil
+	}
+
+	if begin.Is4In6() && end.Is4In6() {
+		begin, end = begin.Unmap(), end.Unmap()
+	}
+
+	if begin == end {
+		return &singleRange{begin.AsSlice()}
+	}
+
+	return &minMaxRange{begin.AsSlice(), end.AsSlice()}
+}
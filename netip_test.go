@@ -0,0 +1,88 @@
+package iprange_test
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestFromPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		res    string
+	}{
+		{"192.168.1.10/24", "192.168.1.0/24"},
+		{"192.168.1.10/32", "192.168.1.10/32"},
+		{"2001:db8::1/120", "2001:db8::/120"},
+		{"::/0", "::/0"},
+		{"::ffff:10.0.0.5/120", "10.0.0.0/24"},
+		{"::ffff:10.0.0.5/128", "10.0.0.5/32"},
+		{"::ffff:0:0/96", "0.0.0.0/0"},
+		{"::ffff:0:0/80", "::ffff:0:0/80"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			r := iprange.FromPrefix(netip.MustParsePrefix(tt.prefix))
+			require.NotNil(t, r)
+			assert.Equal(t, iprange.Parse(tt.res), r)
+		})
+	}
+
+	assert.Nil(t, iprange.FromPrefix(netip.Prefix{}))
+}
+
+func TestFromMapped(t *testing.T) {
+	ip := net.ParseIP("10.0.0.5")
+	addr := netip.MustParseAddr("10.0.0.5")
+
+	for _, r := range []iprange.Range{
+		iprange.FromPrefix(netip.MustParsePrefix("::ffff:10.0.0.0/120")),
+		iprange.FromAddrs(netip.MustParseAddr("::ffff:10.0.0.0"), netip.MustParseAddr("::ffff:10.0.0.255")),
+	} {
+		t.Run(fmt.Sprint(r), func(t *testing.T) {
+			assert.True(t, r.Contains(ip))
+			assert.True(t, iprange.ContainsAddr(r, addr))
+			assert.True(t, iprange.ContainsAddr(r, netip.MustParseAddr("::ffff:10.0.0.5")))
+
+			set := iprange.NewIPSet(r)
+			assert.True(t, set.Contains(ip))
+			assert.Equal(t, iprange.NewIPSet(iprange.Parse("10.0.0.0/24")).String(), set.String())
+		})
+	}
+}
+
+func TestFromAddrs(t *testing.T) {
+	tests := []struct {
+		begin, end string
+		res        string
+	}{
+		{"192.168.1.10", "192.168.2.9", "192.168.1.10_192.168.2.9"},
+		{"2001:db8::1", "2001:db8::10", "2001:db8::1_2001:db8::10"},
+		{"192.168.1.1", "192.168.1.1", "192.168.1.1"},
+		{"::ffff:10.0.0.1", "::ffff:10.0.0.9", "10.0.0.1_10.0.0.9"},
+		{"::ffff:10.0.0.1", "::ffff:10.0.0.1", "10.0.0.1"},
+		{"::ffff:10.0.0.1", "::1:0:0:0", "::ffff:a00:1_::1:0:0:0"},
+
+		// invalid
+		{"192.168.1.10", "192.168.1.9", ""},
+		{"192.168.1.10", "2001:db8::10", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.begin+"_"+tt.end, func(t *testing.T) {
+			r := iprange.FromAddrs(netip.MustParseAddr(tt.begin), netip.MustParseAddr(tt.end))
+			if tt.res == "" {
+				assert.Nil(t, r)
+				return
+			}
+			assert.Equal(t, iprange.Parse(tt.res), r)
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package iprange
 
 import (
+	"net"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 // IP octet bounds.
@@ -33,6 +36,49 @@ func (octs ipOctets) max() []uint16 {
 	return ip
 }
 
+// format returns octets in the form Parse accepts, e.g. "192.168.1,3-5.1-10".
+// Longest run of zero IPv6 groups is replaced with ellipsis.
+func (octs ipOctets) format() string {
+	base, sep := 10, "."
+	if len(octs) != net.IPv4len {
+		base, sep = 16, ":"
+	}
+
+	parts := make([]string, len(octs))
+	for i, oct := range octs {
+		bb := make([]string, len(oct))
+		for j, b := range oct {
+			bb[j] = strconv.FormatUint(uint64(b.lo), base)
+			if b.hi != b.lo {
+				bb[j] += "-" + strconv.FormatUint(uint64(b.hi), base)
+			}
+		}
+		parts[i] = strings.Join(bb, ",")
+	}
+
+	if base == 10 {
+		return strings.Join(parts, sep)
+	}
+
+	// Longest run of zero groups.
+	e, n := -1, 1
+	for i := 0; i < len(parts); i++ {
+		j := i
+		for j < len(parts) && parts[j] == "0" {
+			j++
+		}
+		if j-i > n {
+			e, n = i, j-i
+		}
+	}
+
+	if e < 0 {
+		return strings.Join(parts, sep)
+	}
+
+	return strings.Join(parts[:e], sep) + "::" + strings.Join(parts[e+n:], sep)
+}
+
 // adds bound for i-th octet.
 func (octs ipOctets) push(i int, lo, hi uint16) {
 	if hi == 0 {
@@ -54,11 +100,228 @@ func (octs ipOctets) hasRanges() bool {
 	return false
 }
 
-// sorts octets bounds.
-func (octs ipOctets) sort() {
-	for _, oct := range octs {
-		sort.SliceStable(oct, func(i, j int) bool {
-			return oct[i].lo < oct[j].lo
-		})
+// returns the biggest value of one octet.
+func (octs ipOctets) top() uint16 {
+	return octtop(len(octs))
+}
+
+// returns a copy of octets with every bounds list sorted and merged.
+func (octs ipOctets) normalized() ipOctets {
+	res := make(ipOctets, len(octs))
+	for i, oct := range octs {
+		res[i] = mergeBounds(oct)
+	}
+	return res
+}
+
+// returns true if both octets have the same bounds.
+// Octets must be normalized.
+func (octs ipOctets) equal(other ipOctets) bool {
+	if len(octs) != len(other) {
+		return false
+	}
+	for i := range octs {
+		if !boundsEqual(octs[i], other[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// returns true if octets represent a contiguous range of IP-addresses,
+// i.e. single values, then one bound, then full octets.
+// Octets must be normalized.
+func (octs ipOctets) isInterval() bool {
+	top := octs.top()
+	d := 0
+	for d < len(octs) && len(octs[d]) == 1 && octs[d][0].lo == octs[d][0].hi {
+		d++
+	}
+	if d < len(octs) && len(octs[d]) != 1 {
+		return false
+	}
+	for i := d + 1; i < len(octs); i++ {
+		if len(octs[i]) != 1 || octs[i][0].lo != 0 || octs[i][0].hi != top {
+			return false
+		}
+	}
+	return true
+}
+
+// returns octets of addresses which are in both octs and other, nil if there are none.
+// Octets must be normalized.
+func (octs ipOctets) intersect(other ipOctets) ipOctets {
+	if len(octs) != len(other) {
+		return nil
+	}
+	res := make(ipOctets, len(octs))
+	for i := range octs {
+		res[i] = intersectBounds(octs[i], other[i])
+		if len(res[i]) == 0 {
+			return nil
+		}
+	}
+	return res
+}
+
+// returns disjoint octets of addresses which are in octs, but not in other.
+// Octets must be normalized.
+func (octs ipOctets) subtract(other ipOctets) []ipOctets {
+	common := octs.intersect(other)
+	if common == nil {
+		return []ipOctets{octs}
+	}
+
+	res := make([]ipOctets, 0)
+	for i := range octs {
+		diff := subtractBounds(octs[i], other[i])
+		if len(diff) == 0 {
+			continue
+		}
+		box := make(ipOctets, len(octs))
+		copy(box[:i], common[:i])
+		box[i] = diff
+		copy(box[i+1:], octs[i+1:])
+		res = append(res, box)
+	}
+	return res
+}
+
+// sorts bounds and merges overlapping and adjacent ones.
+func mergeBounds(bb []ipOctet) []ipOctet {
+	sorted := make([]ipOctet, len(bb))
+	copy(sorted, bb)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].lo < sorted[j].lo
+	})
+
+	res := make([]ipOctet, 0, len(sorted))
+	for _, b := range sorted {
+		if n := len(res); n > 0 && int(b.lo) <= int(res[n-1].hi)+1 {
+			if b.hi > res[n-1].hi {
+				res[n-1].hi = b.hi
+			}
+			continue
+		}
+		res = append(res, b)
+	}
+	return res
+}
+
+// returns true if bounds lists are equal.
+func boundsEqual(a, b []ipOctet) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// returns bounds of values which are in both a and b.
+// Bounds must be merged.
+func intersectBounds(a, b []ipOctet) []ipOctet {
+	res := make([]ipOctet, 0)
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		lo, hi := a[i].lo, a[i].hi
+		if b[j].lo > lo {
+			lo = b[j].lo
+		}
+		if b[j].hi < hi {
+			hi = b[j].hi
+		}
+		if lo <= hi {
+			res = append(res, ipOctet{lo, hi})
+		}
+		if a[i].hi < b[j].hi {
+			i++
+		} else {
+			j++
+		}
+	}
+	return res
+}
+
+// returns bounds of values which are in a, but not in b.
+// Bounds must be merged.
+func subtractBounds(a, b []ipOctet) []ipOctet {
+	res := make([]ipOctet, 0)
+	j := 0
+	for _, x := range a {
+		lo, hi := int(x.lo), int(x.hi)
+		for j < len(b) && int(b[j].hi) < lo {
+			j++
+		}
+		for k := j; k < len(b) && int(b[k].lo) <= hi; k++ {
+			if int(b[k].lo) > lo {
+				res = append(res, ipOctet{uint16(lo), b[k].lo - 1})
+			}
+			lo = int(b[k].hi) + 1
+		}
+		if lo <= hi {
+			res = append(res, ipOctet{uint16(lo), uint16(hi)})
+		}
+	}
+	return res
+}
+
+// interval is a contiguous range of IP-addresses between lo and hi inclusive.
+type interval struct {
+	lo, hi []uint16
+}
+
+// returns sorted disjoint intervals of addresses which satisfy the octet boundaries.
+// Octets must be normalized.
+func (octs ipOctets) intervals() []interval {
+	top := octs.top()
+
+	// Last octet which is not full, octets after it can be covered by one interval.
+	l := len(octs) - 1
+	for l > 0 && len(octs[l]) == 1 && octs[l][0].lo == 0 && octs[l][0].hi == top {
+		l--
+	}
+
+	res := make([]interval, 0)
+	cur := octs.min()
+	indexes := make([]int, l)
+
+	for {
+		for _, b := range octs[l] {
+			lo := make([]uint16, len(octs))
+			hi := make([]uint16, len(octs))
+			copy(lo, cur[:l])
+			copy(hi, cur[:l])
+			lo[l], hi[l] = b.lo, b.hi
+			for i := l + 1; i < len(octs); i++ {
+				hi[i] = top
+			}
+			res = append(res, interval{lo, hi})
+		}
+
+		// Next combination of octets before l.
+		i := l - 1
+		for ; i >= 0; i-- {
+			j := indexes[i]
+			if cur[i] < octs[i][j].hi {
+				cur[i]++
+				break
+			} else if j+1 < len(octs[i]) {
+				indexes[i] = j + 1
+				cur[i] = octs[i][j+1].lo
+				break
+			}
+			indexes[i] = 0
+			cur[i] = octs[i][0].lo
+		}
+
+		if i < 0 {
+			break
+		}
 	}
+
+	return res
 }
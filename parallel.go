@@ -0,0 +1,86 @@
+package iprange
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"runtime"
+	"sync"
+)
+
+// Number of addresses ParallelEach hands out to a worker at once.
+const parallelChunk = 1024
+
+// ParallelEach calls fn for every IP-address of the range from multiple goroutines,
+// workers is the number of goroutines, GOMAXPROCS if it is not positive.
+// Addresses are handed out to the workers in disjoint chunks in the order of the range Iterator,
+// so fn is called once for every address the Iterator yields, but in no particular order.
+// Iteration stops at the first error returned by fn or when ctx is done,
+// ParallelEach then waits for the running calls of fn and returns the error.
+func ParallelEach(ctx context.Context, r Range, workers int, fn func(net.IP) error) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunks := &chunkIterator{it: r.Iterator()}
+
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		err  error
+	)
+
+	fail := func(e error) {
+		once.Do(func() {
+			err = e
+			cancel()
+		})
+	}
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			chunk := make([]netip.Addr, parallelChunk)
+			for {
+				n := chunks.next(chunk)
+				if n == 0 {
+					return
+				}
+
+				for _, addr := range chunk[:n] {
+					if ctx.Err() != nil {
+						fail(ctx.Err())
+						return
+					}
+					if e := fn(addr.AsSlice()); e != nil {
+						fail(e)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return err
+}
+
+// chunkIterator hands out disjoint chunks of addresses of the iterator to multiple goroutines.
+type chunkIterator struct {
+	mu sync.Mutex
+	it Iterator
+}
+
+// next saves next addresses into dst and returns their number, 0 if no addresses left.
+func (c *chunkIterator) next(dst []netip.Addr) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return NextN(c.it, dst)
+}
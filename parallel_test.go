@@ -0,0 +1,80 @@
+package iprange_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestParallelEach(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.0/20"},
+		{"10.0.1-3,7.0-255"},
+		{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/116"},
+		{},
+	}
+
+	for _, tt := range tests {
+		for _, workers := range []int{0, 1, 4, 32} {
+			t.Run(fmt.Sprintf("%s/%d", strings.Join(tt, ","), workers), func(t *testing.T) {
+				rr := parseRanges(t, tt...)
+				expected := collect(rr.Iterator())
+				sort.Strings(expected)
+
+				var mu sync.Mutex
+				res := make([]string, 0)
+
+				err := iprange.ParallelEach(context.Background(), rr, workers, func(ip net.IP) error {
+					mu.Lock()
+					defer mu.Unlock()
+					res = append(res, ip.String())
+					return nil
+				})
+				require.NoError(t, err)
+
+				sort.Strings(res)
+				assert.Equal(t, expected, res)
+			})
+		}
+	}
+}
+
+func TestParallelEachError(t *testing.T) {
+	errStop := errors.New("stop")
+
+	var calls int64
+	err := iprange.ParallelEach(context.Background(), iprange.Parse("::/0"), 8, func(ip net.IP) error {
+		if atomic.AddInt64(&calls, 1) == 5000 {
+			return errStop
+		}
+		return nil
+	})
+
+	assert.True(t, errors.Is(err, errStop), err)
+}
+
+func TestParallelEachCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls int64
+	err := iprange.ParallelEach(ctx, iprange.Parse("::/0"), 8, func(ip net.IP) error {
+		if atomic.AddInt64(&calls, 1) == 5000 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.True(t, errors.Is(err, context.Canceled), err)
+}
@@ -1,65 +1,253 @@
 package iprange
 
 import (
+	"fmt"
 	"net"
+	"strings"
 )
 
-type parseFunc func(string) (ipOctets, int)
+// parseFunc parses an IP address with octet ranges.
+// Returns IP octets, characters consumed and the reason of failure if IP octets are nil.
+type parseFunc func(string, *parseOptions) (ipOctets, int, string)
+
+// Reasons of parse errors.
+const (
+	reasonNotIP          = "not an IP address"
+	reasonNoDecimal      = "expected decimal number"
+	reasonNoHex          = "expected hexadecimal number"
+	reasonOctetOverflow  = "octet > 255"
+	reasonGroupOverflow  = "group > ffff"
+	reasonTooManyDashes  = "too many dashes"
+	reasonTooFewOctets   = "too few octets"
+	reasonTooFewGroups   = "too few groups"
+	reasonDoubleEllipsis = "double ellipsis"
+	reasonEmptyEllipsis  = "ellipsis must represent at least one group"
+	reasonOctetRanges    = "octet ranges are not allowed here"
+	reasonFamilyMismatch = "address family mismatch"
+	reasonReversed       = "begin is greater than end"
+	reasonNoMask         = "expected decimal mask"
+	reasonMaskBounds     = "mask out of bounds"
+	reasonUnexpected     = "unexpected character"
+	reasonWildcardRange  = "wildcard can not be a bound of octet range"
+	reasonNotNmap        = "not supported by nmap"
+	reasonNonContiguous  = "netmask is not contiguous"
+	reasonEmptyEntry     = "empty entry"
+)
+
+// ParseError describes a problem parsing an IP addresses range.
+type ParseError struct {
+	// Input is the string being parsed.
+	Input string
+
+	// Offset is the byte offset in Input where parsing failed.
+	Offset int
+
+	// Reason describes the problem, e.g. "octet > 255".
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("iprange: invalid range %q at offset %d: %s", e.Input, e.Offset, e.Reason)
+}
+
+// ParseOption configures parsing of ranges and lists of ranges.
+type ParseOption func(*parseOptions)
+
+type parseOptions struct {
+	allErrors bool
+	nmap      bool
+}
+
+// AllErrors makes list parsers report all invalid entries instead of stopping at the first one.
+func AllErrors() ParseOption {
+	return func(o *parseOptions) {
+		o.allErrors = true
+	}
+}
+
+// NmapCompatible makes parsers accept exactly the IP-addresses ranges nmap target specifications accept:
+// single addresses, CIDR ranges and IPv4 octets ranges with wildcards and open-ended octet ranges.
+// Begin_end ranges, IPv6 octets ranges, reversed octet ranges like "10-1", and in lists
+// comma separators and exclusions are rejected. Host names are not supported.
+func NmapCompatible() ParseOption {
+	return func(o *parseOptions) {
+		o.nmap = true
+	}
+}
+
+// newParseOptions returns options with all the given ones applied.
+func newParseOptions(opts []ParseOption) *parseOptions {
+	o := &parseOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
 
 // Parse parses s as an IP addresses range (IPv4 or IPv6), returning the result.
 // The string s can be in the following formats:
-// single IP ("192.0.2.1", "2001:db8::68"), CIDR range ("192.168.1.0/24", "2001:db8::68/120"),
-// begin_end range ("192.168.1.1_192.168.1.10", "2001:db8::68_2001:db8::80") or
+// single IP ("192.0.2.1", "2001:db8::68"),
+// CIDR range ("192.168.1.0/24", "192.168.1.0/255.255.255.0", "2001:db8::68/120"),
+// address with ACL wildcard mask ("10.0.0.0 0.0.255.255", "10.0.0.1 0.0.254.0"),
+// begin_end range ("192.168.1.1_192.168.1.10", "2001:db8::68_2001:db8::80"),
+// begin-end range ("192.168.1.1-192.168.1.10", "2001:db8::68-2001:db8::80") or
 // octets range ("192.168.1,3,5.1-10", "2001:db8::0,1:68-80").
+// In octets ranges "*" is any value of the octet and ranges can be open-ended:
+// "10-" is 10-255 and "-20" is 0-20 ("192.168.*.1", "10.0-.0.1", "2001:db8::*:1").
+// A dash separates begin and end of a begin-end range if both of them are full addresses,
+// such strings are never valid octets ranges, which can not have more octets than an address.
+// Otherwise the dash is a bound of an octet range, so the short form "192.168.1.1-10"
+// is an octets range with the same addresses as "192.168.1.1-192.168.1.10".
+// Bits set in a wildcard mask can have any value, so non-contiguous masks give octets ranges
+// ("10.0.0.1 0.0.254.0" is "10.0.0,2,4,...,254.1").
+// Begin of begin_end and begin-end ranges can not be greater than end, such ranges are invalid rather than empty.
 // If s is not a valid textual representation of an IP addresses range,
-// ParseIP returns nil.
+// Parse returns nil. Use ParseRange to find out why.
 func Parse(s string) Range {
+	r, err := ParseRange(s)
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// ParseRange is like Parse, but returns a *ParseError describing the problem
+// if s is not a valid textual representation of an IP addresses range.
+// Options can restrict the accepted formats, see NmapCompatible.
+func ParseRange(s string, opts ...ParseOption) (Range, error) {
+	return parseRange(s, newParseOptions(opts))
+}
+
+// parseRange parses s as an IP addresses range with the given options.
+func parseRange(s string, o *parseOptions) (Range, error) {
+	parseFn, iplen := parserOf(s)
+	if parseFn == nil {
+		return nil, &ParseError{s, 0, reasonNotIP}
+	}
+	return parse(s, parseFn, iplen, o)
+}
+
+// parserOf returns parse function and IP length of the address family of s,
+// which is decided by the first dot or colon in s, or nil if there is none.
+func parserOf(s string) (parseFunc, int) {
 	for i := 0; i < len(s); i++ {
 		switch s[i] {
 		case '.':
-			return parse(s, parseIPv4, net.IPv4len)
+			return parseIPv4, net.IPv4len
 		case ':':
-			return parse(s, parseIPv6, net.IPv6len)
+			return parseIPv6, net.IPv6len
 		}
 	}
-	return nil
+	return nil, 0
 }
 
 // parse decides which kind of range is s: singleRange, cidrRange, minMaxRange or octetsRange.
-func parse(s string, parseFn parseFunc, iplen int) Range {
-	ip, c := parseFn(s)
+func parse(in string, parseFn parseFunc, iplen int, o *parseOptions) (Range, error) {
+	s := in
+	off := 0 // offset of s in the input
+
+	fail := func(off int, reason string) (Range, error) {
+		return nil, &ParseError{in, off, reason}
+	}
+
+	if r, ok, err := parseDashRange(in, parseFn, iplen, o); ok {
+		return r, err
+	}
+
+	ip, c, reason := parseFn(s, o)
 
 	if ip == nil {
-		return nil
+		return fail(off+c, reason)
+	}
+
+	if o.nmap && iplen == net.IPv6len && ip.hasRanges() {
+		return fail(0, reasonNotNmap)
 	}
 
 	s = s[c:]
+	off += c
 
 	if len(s) > 0 && s[0] == '_' {
 		// begin_end range.
 
+		if o.nmap {
+			return fail(off, reasonNotNmap)
+		}
+
 		if ip.hasRanges() {
 			// Already have octet ranges.
-			return nil
+			return fail(0, reasonOctetRanges)
 		}
 
 		s = s[1:]
+		off++
 
-		max, c := parseFn(s)
+		max, c, reason := parseFn(s, o)
 
-		if max == nil || max.hasRanges() || len(ip) != len(max) {
-			// Invalid ip or have octet ranges.
-			return nil
+		if max == nil {
+			return fail(off+c, reason)
+		}
+
+		if max.hasRanges() {
+			return fail(off, reasonOctetRanges)
+		}
+
+		if len(ip) != len(max) {
+			return fail(off, reasonFamilyMismatch)
+		}
+
+		if octcmp(ip.min(), max.min()) == 1 {
+			return fail(off, reasonReversed)
 		}
 
 		s = s[c:]
+		off += c
 
 		// Must have used entire string.
 		if len(s) != 0 {
-			return nil
+			return fail(off, reasonUnexpected)
 		}
 
-		return &minMaxRange{octets2ip(ip.min()), octets2ip(max.min())}
+		return &minMaxRange{octets2ip(ip.min()), octets2ip(max.min())}, nil
+	}
+
+	if t := strings.TrimLeft(s, " \t"); len(t) < len(s) && len(t) > 0 {
+		// Address with ACL wildcard mask, trailing whitespace is unexpected.
+
+		if o.nmap {
+			return fail(off, reasonNotNmap)
+		}
+
+		if ip.hasRanges() {
+			// Already have octet ranges.
+			return fail(0, reasonOctetRanges)
+		}
+
+		for len(s) > 0 && (s[0] == ' ' || s[0] == '\t') {
+			s = s[1:]
+			off++
+		}
+
+		wildcard, c, reason := parseFn(s, o)
+
+		if wildcard == nil {
+			return fail(off+c, reason)
+		}
+
+		if wildcard.hasRanges() {
+			return fail(off, reasonOctetRanges)
+		}
+
+		if len(wildcard) != len(ip) {
+			return fail(off, reasonFamilyMismatch)
+		}
+
+		// Must have used entire string.
+		if c != len(s) {
+			return fail(off+c, reasonUnexpected)
+		}
+
+		return wildcard2range(ip.min(), wildcard.min()), nil
 	}
 
 	if len(s) > 0 && s[0] == '/' {
@@ -67,58 +255,149 @@ func parse(s string, parseFn parseFunc, iplen int) Range {
 
 		if ip.hasRanges() {
 			// Already have octet ranges.
-			return nil
+			return fail(0, reasonOctetRanges)
 		}
 
 		s = s[1:]
+		off++
+
+		if strings.ContainsAny(s, ".:") {
+			// Netmask, e.g. "255.255.255.0".
+
+			if o.nmap {
+				return fail(off, reasonNotNmap)
+			}
+
+			mask, c, reason := parseFn(s, o)
+
+			if mask == nil {
+				return fail(off+c, reason)
+			}
+
+			if mask.hasRanges() {
+				return fail(off, reasonOctetRanges)
+			}
+
+			if len(mask) != len(ip) {
+				return fail(off, reasonFamilyMismatch)
+			}
+
+			// Must have used entire string.
+			if c != len(s) {
+				return fail(off+c, reasonUnexpected)
+			}
+
+			n, bits := net.IPMask(octets2ip(mask.min())).Size()
+			if bits == 0 {
+				return fail(off, reasonNonContiguous)
+			}
+
+			return cidr2range(octets2ip(ip.min()), n, bits), nil
+		}
 
 		// Decimal mask.
 		n, c, ok := dtoi(s)
+		if c == 0 {
+			return fail(off, reasonNoMask)
+		}
 		if !ok || n < 0 || n > 8*iplen {
-			return nil
+			return fail(off, reasonMaskBounds)
 		}
 
 		s = s[c:]
+		off += c
 
 		// Must have used entire string.
 		if len(s) != 0 {
-			return nil
+			return fail(off, reasonUnexpected)
 		}
 
-		mask := net.CIDRMask(n, iplen*8)
-		min := octets2ip(ip.min()).Mask(mask)
-		max := make(net.IP, len(min))
-
-		for i, m := range mask {
-			if m == 0xff {
-				max[i] = min[i]
-			} else {
-				max[i] = min[i] | (m ^ 0xff)
-			}
-		}
-
-		return &minMaxRange{min, max}
+		return cidr2range(octets2ip(ip.min()), n, iplen*8), nil
 	}
 
 	// Must have used entire string.
 	if len(s) != 0 {
-		return nil
+		return fail(off, reasonUnexpected)
 	}
 
 	if !ip.hasRanges() {
 		// singleRange ip.
-		return &singleRange{octets2ip(ip.min())}
+		return &singleRange{octets2ip(ip.min())}, nil
+	}
+
+	// Sort and merge octets bounds, so overlapping bounds are counted once.
+	return &octetsRange{ip.normalized()}, nil
+}
+
+// parseDashRange parses in as begin-end range with full begin and end addresses,
+// e.g. "10.0.0.250-10.0.1.5" or "2001:db8::1-2001:db8::ff".
+// Returns false if in is not such range, so it must be parsed as octets range.
+// IPv4 octets ranges never have a dot after a full address and a dash, so the rest
+// of IPv4 range must be a valid end.
+func parseDashRange(in string, parseFn parseFunc, iplen int, o *parseOptions) (Range, bool, error) {
+	i := strings.IndexByte(in, '-')
+	if i <= 0 || !strings.ContainsAny(in[i+1:], ".:") {
+		// Octet range in one octet, e.g. "10.0.0.1-10".
+		return nil, false, nil
+	}
+
+	min, c, _ := parseFn(in[:i], o)
+	if min == nil || c != i || min.hasRanges() {
+		// Octet range before the dash, e.g. "10.0-1.0.1".
+		return nil, false, nil
+	}
+
+	off := i + 1
+	s := in[off:]
+
+	// End can be of another family, which is an error reported below.
+	endFn, _ := parserOf(s)
+
+	if iplen == net.IPv6len {
+		// Colons can follow an octet range too, e.g. "2001:db8::1-10:1",
+		// so the end must be a full address.
+		max, c, _ := endFn(s, o)
+		if max == nil || c != len(s) || max.hasRanges() {
+			return nil, false, nil
+		}
+	}
+
+	fail := func(off int, reason string) (Range, bool, error) {
+		return nil, true, &ParseError{in, off, reason}
+	}
+
+	if o.nmap {
+		return fail(i, reasonNotNmap)
 	}
 
-	// Sort ip2octets.
-	ip.sort()
+	max, c, reason := endFn(s, o)
+
+	if max == nil {
+		return fail(off+c, reason)
+	}
+
+	if max.hasRanges() {
+		return fail(off, reasonOctetRanges)
+	}
 
-	return &octetsRange{ip}
+	if c != len(s) {
+		return fail(off+c, reasonUnexpected)
+	}
+
+	if len(min) != len(max) {
+		return fail(off, reasonFamilyMismatch)
+	}
+
+	if octcmp(min.min(), max.min()) == 1 {
+		return fail(off, reasonReversed)
+	}
+
+	return &minMaxRange{octets2ip(min.min()), octets2ip(max.min())}, true, nil
 }
 
 // parseIPv4 parses s as IPv4, based on net.parseIPv4.
-// Returns IP octets, characters consumed.
-func parseIPv4(s string) (ip ipOctets, cc int) {
+// Returns IP octets, characters consumed and the reason of failure.
+func parseIPv4(s string, o *parseOptions) (ip ipOctets, cc int, reason string) {
 	ip = make(ipOctets, net.IPv4len)
 
 	for i := 0; i < net.IPv4len; i++ {
@@ -126,26 +405,42 @@ func parseIPv4(s string) (ip ipOctets, cc int) {
 	}
 
 	var bb [2]uint16 // octet bounds: 0 - lo, 1 - hi
+	i := 0           // octet idx
+	k := 0           // bound idx: 0 - lo, 1 - hi
 
-	i := 0 // octet idx
-	k := 0 // bound idx: 0 - lo, 1 - hi
+	// Saves bounds of i-th octet, false if they are reversed and it is not allowed.
+	push := func() bool {
+		if o.nmap && k == 1 && bb[0] > bb[1] {
+			return false
+		}
+		ip.push(i, bb[0], bb[1])
+		return true
+	}
 
 loop:
 	for i < net.IPv4len {
 		// Decimal number.
-		n, c, ok := dtoi(s)
-		if !ok || n > 0xFF {
-			return nil, cc
+		n, c, wildcard, ok := parseBound(s, k, 0xFF, dtoi)
+		if c == 0 && !ok {
+			return nil, cc, reasonNoDecimal
+		}
+		if !ok {
+			return nil, cc, reasonOctetOverflow
 		}
 
 		// Save bound.
-		bb[k] = uint16(n)
+		bb[k] = n
+		if wildcard {
+			bb[1] = 0xFF
+		}
 
 		// Stop at max of string.
 		s = s[c:]
 		cc += c
 		if len(s) == 0 {
-			ip.push(i, bb[0], bb[1])
+			if !push() {
+				return nil, cc, reasonReversed
+			}
 			i++
 			break
 		}
@@ -155,17 +450,24 @@ loop:
 		case '.':
 			fallthrough
 		case ',':
-			ip.push(i, bb[0], bb[1])
+			if !push() {
+				return nil, cc, reasonReversed
+			}
 			bb[1] = 0
 			k = 0
 		case '-':
 			if k == 1 {
 				// To many dashes in one octet.
-				return nil, cc
+				return nil, cc, reasonTooManyDashes
+			}
+			if wildcard {
+				return nil, cc, reasonWildcardRange
 			}
 			k++
 		default:
-			ip.push(i, bb[0], bb[1])
+			if !push() {
+				return nil, cc, reasonReversed
+			}
 			i++
 			break loop
 		}
@@ -180,15 +482,40 @@ loop:
 
 	if i < net.IPv4len {
 		// Missing ip2octets.
-		return nil, cc
+		return nil, cc, reasonTooFewOctets
 	}
 
-	return ip, cc
+	return ip, cc, ""
+}
+
+// parseBound parses k-th bound of octet range: a number, "*" wildcard if k is 0
+// or nothing for open-ended ranges, i.e. 0 if k is 0 and the range follows, top if k is 1.
+// Returns the bound, characters consumed, true if the bound is wildcard
+// and false if there is no number or it is greater than top.
+func parseBound(s string, k int, top uint16, atoi func(string) (int, int, bool)) (uint16, int, bool, bool) {
+	if k == 0 && len(s) > 0 {
+		switch s[0] {
+		case '*':
+			return 0, 1, true, true
+		case '-':
+			return 0, 0, false, true
+		}
+	}
+
+	n, c, ok := atoi(s)
+	if c == 0 {
+		return top, 0, false, k == 1
+	}
+	if !ok || n > int(top) {
+		return 0, c, false, false
+	}
+
+	return uint16(n), c, false, true
 }
 
 // parseIPv6 parses s as IPv6, based on net.parseIPv6.
-// Returns IP octets, characters consumed.
-func parseIPv6(s string) (ip ipOctets, cc int) {
+// Returns IP octets, characters consumed and the reason of failure.
+func parseIPv6(s string, o *parseOptions) (ip ipOctets, cc int, reason string) {
 	ip = make(ipOctets, net.IPv6len/2)
 
 	for i := 0; i < net.IPv6len/2; i++ {
@@ -208,7 +535,7 @@ func parseIPv6(s string) (ip ipOctets, cc int) {
 			for i := 0; i < net.IPv6len/2; i++ {
 				ip.push(i, 0, 0)
 			}
-			return ip, cc
+			return ip, cc, ""
 		}
 	}
 
@@ -220,20 +547,26 @@ func parseIPv6(s string) (ip ipOctets, cc int) {
 loop:
 	for i < net.IPv6len/2 {
 		// Hex number.
-		n, c, ok := xtoi(s)
-		if !ok || n > 0xFFFF {
-			return nil, cc
+		n, c, wildcard, ok := parseBound(s, k, 0xFFFF, xtoi)
+		if c == 0 && !ok {
+			return nil, cc, reasonNoHex
+		}
+		if !ok {
+			return nil, cc, reasonGroupOverflow
 		}
 
 		// If followed by dot, might be in trailing net.IPv4.
-		if c < len(s) && s[c] == '.' {
-			ip, n := parseIPv4(s)
+		if c > 0 && c < len(s) && s[c] == '.' {
+			ip, n, reason := parseIPv4(s, o)
 
-			return ip, cc + n
+			return ip, cc + n, reason
 		}
 
 		// Save this 16-bit chunk.
-		bb[k] = uint16(n)
+		bb[k] = n
+		if wildcard {
+			bb[1] = 0xFFFF
+		}
 
 		// Stop at max of string.
 		s = s[c:]
@@ -254,11 +587,15 @@ loop:
 		case '-':
 			if k == 1 {
 				// To many dashes in one octet.
-				return nil, cc
+				return nil, cc, reasonTooManyDashes
+			}
+			if wildcard {
+				return nil, cc, reasonWildcardRange
 			}
 			k++
 		default:
 			ip.push(i, bb[0], bb[1])
+			i++
 			break loop
 		}
 
@@ -270,9 +607,9 @@ loop:
 		cc++
 
 		// Look for ellipsis.
-		if s[0] == ':' {
+		if len(s) > 0 && s[0] == ':' {
 			if ellipsis >= 0 { // already have one
-				return nil, cc
+				return nil, cc, reasonDoubleEllipsis
 			}
 			ellipsis = i
 			s = s[1:]
@@ -286,7 +623,7 @@ loop:
 	// If didn't parse enough, expand ellipsis.
 	if i < net.IPv6len/2 {
 		if ellipsis < 0 {
-			return nil, cc
+			return nil, cc, reasonTooFewGroups
 		}
 		n := net.IPv6len/2 - i
 		for j := i - 1; j >= ellipsis; j-- {
@@ -297,8 +634,8 @@ loop:
 		}
 	} else if ellipsis >= 0 {
 		// Ellipsis must represent at least one 0 group.
-		return nil, cc
+		return nil, cc, reasonEmptyEllipsis
 	}
 
-	return ip, cc
+	return ip, cc, ""
 }
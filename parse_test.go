@@ -1,10 +1,12 @@
 package iprange_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/russtone/iprange"
 )
@@ -40,6 +42,7 @@ func TestInvalid(t *testing.T) {
 		"192.168.1.1-192.168",
 		"192.168.1.1-192.168.1.10/24",
 		"192.168.1.1-1:2::1",
+		"192.168.1.10_192.168.1.9",
 
 		// Octet
 		"192.168.1.1-300",
@@ -55,6 +58,8 @@ func TestInvalid(t *testing.T) {
 		":100:abab:10000",
 		"::100:abab:dead::",
 		"1:2:3:4:5:6:7:8:9",
+		"1:",
+		"1::2:",
 		"1:2:3:4",
 		"1:2:3:4::5:6:7:8",
 
@@ -95,9 +100,13 @@ func TestValid(t *testing.T) {
 
 		// begin_end
 		"192.168.1.1_192.168.2.10",
+		"192.168.1.1-192.168.2.10",
 
 		// octets
 		"192.168.1,2-5.1,2,3",
+		"192.168.*.1",
+		"10.0-.0.1",
+		"10.-20.0.1",
 
 		//
 		// IPv6
@@ -117,10 +126,13 @@ func TestValid(t *testing.T) {
 
 		// begin_end
 		"1:2:3:4::abab:1_1:2:3:4::abab:10",
+		"1:2:3:4:5:6:7:8_1:2:3:4:5:6:7:9",
 		"1:2:3:4::_1:2:3:4::5",
+		"2001:db8::1-2001:db8::ff",
 
 		// octets
 		"1:2:3:4::1-10:1,2,ffff",
+		"2001:db8::*:1",
 	}
 
 	for i, tt := range tests {
@@ -130,3 +142,200 @@ func TestValid(t *testing.T) {
 		})
 	}
 }
+
+func TestParseError(t *testing.T) {
+	tests := []struct {
+		s      string
+		offset int
+		reason string
+	}{
+		// Misc
+		{"", 0, "not an IP address"},
+		{"invalid", 0, "not an IP address"},
+
+		// IPv4
+		{"192.168.300.1", 8, "octet > 255"},
+		{"192.168.1.", 10, "expected decimal number"},
+		{"192.168.1", 9, "too few octets"},
+		{"192.168.1.1-2-3", 13, "too many dashes"},
+		{"192.168.1.1/33", 12, "mask out of bounds"},
+		{"192.168.1.1/", 12, "expected decimal mask"},
+		{"192.168.1.1/24/2", 14, "unexpected character"},
+		{"192.168.1-2.1/24", 0, "octet ranges are not allowed here"},
+		{"192.168.1.1_192.168.1.1-2", 12, "octet ranges are not allowed here"},
+		{"192.168.1.1_192.168", 19, "too few octets"},
+		{"192.168.1.10_192.168.1.9", 13, "begin is greater than end"},
+		{"10.0.0.1-10.0.0.300", 16, "octet > 255"},
+		{"10.0.0.5-10.0.0.1", 9, "begin is greater than end"},
+		{"10.0.0.1-2001:db8::1", 9, "address family mismatch"},
+		{"1::1-1.2.3.4", 5, "address family mismatch"},
+		{"::-1.2.3.4", 3, "address family mismatch"},
+		{"10.0.0.1-10.0.*.5", 9, "octet ranges are not allowed here"},
+		{"10.0.0.1-10.0.0.5/24", 17, "unexpected character"},
+		{"10.0.0.0/255.0.255.0", 9, "netmask is not contiguous"},
+		{"10.0.0.0/255.255.255.0x", 22, "unexpected character"},
+		{"10.0.0.0/255.255.0-1.0", 9, "octet ranges are not allowed here"},
+		{"10.0.0.0 0.0.300.0", 13, "octet > 255"},
+		{"::/255.255.0.0", 3, "address family mismatch"},
+		{"::ffff:0:0/255.255.0.0", 11, "address family mismatch"},
+		{"::1 0.0.0.255", 4, "address family mismatch"},
+		{"10.0.0.1 ", 8, "unexpected character"},
+		{"10.0.0.1 \t ", 8, "unexpected character"},
+		{"2001:db8::1 ", 11, "unexpected character"},
+		{"10.0.0.0,1 0.0.255.255", 0, "octet ranges are not allowed here"},
+		{"10.*-5.0.1", 4, "wildcard can not be a bound of octet range"},
+		{"10.0.0.**", 8, "unexpected character"},
+
+		// IPv6
+		{"::100:abab:10000", 11, "group > ffff"},
+		{"::100:abab:dead::", 16, "double ellipsis"},
+		{"1:2:3:4", 7, "too few groups"},
+		{"1:2:3:4::5:6:7:8", 16, "ellipsis must represent at least one group"},
+		{"1:", 2, "expected hexadecimal number"},
+		{"1::abab:dead/129", 13, "mask out of bounds"},
+		{"1-2-3::abab:dead", 3, "too many dashes"},
+		{"1::2_1.2.3.4", 5, "address family mismatch"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, tt.s), func(t *testing.T) {
+			r, err := iprange.ParseRange(tt.s)
+			assert.Nil(t, r)
+			require.Error(t, err)
+
+			var perr *iprange.ParseError
+			require.True(t, errors.As(err, &perr))
+			assert.Equal(t, tt.s, perr.Input)
+			assert.Equal(t, tt.offset, perr.Offset)
+			assert.Equal(t, tt.reason, perr.Reason)
+		})
+	}
+}
+
+func TestParseDashRange(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{"10.0.0.250-10.0.1.5", "10.0.0.250_10.0.1.5"},
+		{"10.0.0.1-10.0.0.1", "10.0.0.1/32"},
+		{"2001:db8::1-2001:db8::ff", "2001:db8::1_2001:db8::ff"},
+		{"2001:db8::ffff-2001:db8::1:0", "2001:db8::ffff_2001:db8::1:0"},
+
+		// Octets ranges.
+		{"192.168.1.1-10", "192.168.1.1-10"},
+		{"10.0-1.0.1", "10.0-1.0.1"},
+		{"2001:db8::1-10:1", "2001:db8::1-10:1"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, tt.s), func(t *testing.T) {
+			r, err := iprange.ParseRange(tt.s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fmt.Sprint(r))
+		})
+	}
+}
+
+func TestParseMask(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+		count    int64
+	}{
+		// Netmasks.
+		{"192.168.1.0/255.255.255.0", "192.168.1.0/24", 256},
+		{"192.168.1.7/255.255.255.252", "192.168.1.4/30", 4},
+		{"10.0.0.0/0.0.0.0", "0.0.0.0/0", 1 << 32},
+		{"2001:db8::/ffff:ffff:ffff:ffff:ffff:ffff:ffff:ff00", "2001:db8::/120", 256},
+
+		// Wildcard masks.
+		{"10.0.0.0 0.0.255.255", "10.0.0.0/16", 65536},
+		{"10.0.5.1\t0.0.0.255", "10.0.5.0/24", 256},
+		{"10.0.0.0  0.0.0.0", "10.0.0.0/32", 1},
+		{"10.0.0.0 0.0.1.1", "10.0.0-1.0-1", 4},
+		{"10.0.0.1 0.0.0.6", "10.0.0.1,3,5,7", 4},
+		{"10.0.3.0 0.0.4.3", "10.0.3,7.0-3", 8},
+		{"2001:db8::1 ::5:0", "2001:db8::0-1,4-5:1", 4},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, tt.s), func(t *testing.T) {
+			r, err := iprange.ParseRange(tt.s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fmt.Sprint(r))
+			assert.Equal(t, tt.count, r.Count().Int64())
+		})
+	}
+}
+
+func TestParseWildcards(t *testing.T) {
+	tests := []struct {
+		s        string
+		expected string
+	}{
+		{"192.168.*.1", "192.168.0-255.1"},
+		{"*.*.*.*", "0-255.0-255.0-255.0-255"},
+		{"10.0-.0.1", "10.0-255.0.1"},
+		{"10.-20.0.1", "10.0-20.0.1"},
+		{"10.-.0.1", "10.0-255.0.1"},
+		{"10.0.0.1-", "10.0.0.1-255"},
+		{"10.0.1,5-.1", "10.0.1,5-255.1"},
+		{"2001:db8::*:1", "2001:db8::0-ffff:1"},
+		{"2001:db8::-ff:1", "2001:db8::0-ff:1"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, tt.s), func(t *testing.T) {
+			r, err := iprange.ParseRange(tt.s)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, fmt.Sprint(r))
+		})
+	}
+}
+
+func TestParseNmapCompatible(t *testing.T) {
+	valid := []string{
+		"192.168.1.1",
+		"192.168.1.0/24",
+		"2001:db8::1",
+		"2001:db8::/120",
+		"192.168.*.1",
+		"10.0-.0.1",
+		"10.0.0,2-5.-10",
+	}
+
+	for i, tt := range valid {
+		t.Run(fmt.Sprintf("valid/%d/%s", i, tt), func(t *testing.T) {
+			r, err := iprange.ParseRange(tt, iprange.NmapCompatible())
+			require.NoError(t, err)
+			assert.NotNil(t, r)
+		})
+	}
+
+	invalid := []struct {
+		s      string
+		offset int
+		reason string
+	}{
+		{"192.168.1.1_192.168.1.10", 11, "not supported by nmap"},
+		{"2001:db8::1-2", 0, "not supported by nmap"},
+		{"2001:db8::*:1", 0, "not supported by nmap"},
+		{"192.168.1.1-192.168.1.10", 11, "not supported by nmap"},
+		{"192.168.1.0/255.255.255.0", 12, "not supported by nmap"},
+		{"10.0.0.0 0.0.255.255", 8, "not supported by nmap"},
+		{"10.0.0.10-5", 11, "begin is greater than end"},
+	}
+
+	for i, tt := range invalid {
+		t.Run(fmt.Sprintf("invalid/%d/%s", i, tt.s), func(t *testing.T) {
+			r, err := iprange.ParseRange(tt.s, iprange.NmapCompatible())
+			assert.Nil(t, r)
+
+			var perr *iprange.ParseError
+			require.True(t, errors.As(err, &perr))
+			assert.Equal(t, tt.offset, perr.Offset)
+			assert.Equal(t, tt.reason, perr.Reason)
+		})
+	}
+}
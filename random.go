@@ -0,0 +1,212 @@
+package iprange
+
+import (
+	"net"
+	"net/netip"
+)
+
+//
+// Random order iterator.
+//
+
+type randomIterator struct {
+	Ranges
+	seed    uint64
+	indexed *indexedRanges
+	perm    feistel
+	current uint128
+	done    bool
+}
+
+var _ Iterator = &randomIterator{}
+
+// RandomIterator returns iterator which yields every address of the range exactly once
+// in a pseudorandom order determined by the seed.
+// Addresses are not materialized, the order is a keyed permutation of their indexes,
+// so the iterator works for huge IPv6 ranges and is reproducible from the seed.
+func RandomIterator(r Range, seed uint64) Iterator {
+	rr := Ranges{r}.Normalize()
+
+	indexed, ok := newIndexedRanges(rr)
+	if !ok {
+		// More than 2^128 addresses, iterate each family separately.
+		var v4, v6 Ranges
+		for _, r := range rr {
+			if r.(indexer).at(uint128{}).Is4() {
+				v4 = append(v4, r)
+			} else {
+				v6 = append(v6, r)
+			}
+		}
+		its := []Iterator{RandomIterator(v4, seed), RandomIterator(v6, seed)}
+		return &splitRandomIterator{&rangesIterator{its: its}, seed}
+	}
+
+	it := &randomIterator{Ranges: rr, seed: seed, indexed: indexed, perm: newFeistel(indexed.last, seed)}
+	it.Reset()
+	return it
+}
+
+func (it *randomIterator) Next(out *net.IP) bool {
+	var addr netip.Addr
+	if !it.NextAddr(&addr) {
+		return false
+	}
+
+	*out = addr.AsSlice()
+
+	return true
+}
+
+func (it *randomIterator) NextInto(out *net.IP) bool {
+	var addr netip.Addr
+	if !it.NextAddr(&addr) {
+		return false
+	}
+
+	*out = reuseIP(*out, addr.BitLen()/8)
+	u128FromAddr(addr).putIP(*out)
+
+	return true
+}
+
+func (it *randomIterator) NextAddr(out *netip.Addr) bool {
+	if it.done {
+		return false
+	}
+
+	*out = it.indexed.at(it.perm.permute(it.current))
+
+	if it.current == it.indexed.last {
+		it.done = true
+	} else {
+		it.current = it.current.add64(1)
+	}
+
+	return true
+}
+
+func (it *randomIterator) NextN(dst []netip.Addr) int {
+	n := 0
+	for n < len(dst) && it.NextAddr(&dst[n]) {
+		n++
+	}
+
+	return n
+}
+
+func (it *randomIterator) Reset() {
+	it.current = uint128{}
+	it.done = it.indexed.empty()
+}
+
+// splitRandomIterator iterates IPv4 and IPv6 addresses in random order separately,
+// it is used if there are too many addresses for one permutation.
+type splitRandomIterator struct {
+	*rangesIterator
+	seed uint64
+}
+
+var _ Iterator = &splitRandomIterator{}
+
+//
+// Feistel network.
+//
+
+// feistel is a keyed pseudorandom permutation of integers in [0, last].
+// Balanced Feistel network permutes integers of even number of bits,
+// integers greater than last are skipped by cycle walking.
+type feistel struct {
+	last uint128
+	half uint // number of bits in one half
+	keys [4]uint64
+}
+
+func newFeistel(last uint128, seed uint64) feistel {
+	n := last.bitLen()
+	if n < 2 {
+		n = 2
+	}
+	if n%2 == 1 {
+		n++
+	}
+
+	f := feistel{last: last, half: uint(n / 2)}
+	for i := range f.keys {
+		seed += 0x9e3779b97f4a7c15
+		f.keys[i] = mix64(seed)
+	}
+
+	return f
+}
+
+// permute returns the image of x, x must not be greater than last.
+func (f feistel) permute(x uint128) uint128 {
+	for {
+		x = f.encrypt(x)
+		if x.cmp(f.last) <= 0 {
+			return x
+		}
+	}
+}
+
+// unpermute returns the preimage of x, x must not be greater than last.
+func (f feistel) unpermute(x uint128) uint128 {
+	for {
+		x = f.decrypt(x)
+		if x.cmp(f.last) <= 0 {
+			return x
+		}
+	}
+}
+
+// encrypt applies Feistel rounds to x.
+func (f feistel) encrypt(x uint128) uint128 {
+	mask := f.mask()
+	l, r := f.split(x)
+
+	for _, key := range f.keys {
+		l, r = r, l^(mix64(r^key)&mask)
+	}
+
+	return f.join(l, r)
+}
+
+// decrypt applies Feistel rounds to x in reverse order.
+func (f feistel) decrypt(x uint128) uint128 {
+	l, r := f.split(x)
+
+	for i := len(f.keys) - 1; i >= 0; i-- {
+		l, r = r^(mix64(l^f.keys[i])&f.mask()), l
+	}
+
+	return f.join(l, r)
+}
+
+// mask returns mask of one half.
+func (f feistel) mask() uint64 {
+	return ^uint64(0) >> (64 - f.half)
+}
+
+// split returns halves of x.
+func (f feistel) split(x uint128) (l, r uint64) {
+	if f.half == 64 {
+		return x.hi, x.lo
+	}
+	return (x.lo>>f.half | x.hi<<(64-f.half)) & f.mask(), x.lo & f.mask()
+}
+
+// join returns integer from its halves.
+func (f feistel) join(l, r uint64) uint128 {
+	if f.half == 64 {
+		return uint128{l, r}
+	}
+	return uint128{l >> (64 - f.half), l<<f.half | r}
+}
+
+// mix64 is the finalizer of splitmix64 generator.
+func mix64(z uint64) uint64 {
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
@@ -0,0 +1,83 @@
+package iprange_test
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestRandomIterator(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.0/31"},
+		{"10.0.0.0/24"},
+		{"10.0.0.7_10.0.3.200"},
+		{"10.0.1-3,7.1-10,20-30"},
+		{"10.0.0.0/24", "10.0.0.0/25", "10.0.0-3.1-10"},
+		{"2001:db8::/120", "2001:db8::1-2:1-10", "10.0.0.0/30"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, strings.Join(tt, ",")), func(t *testing.T) {
+			rr := parseRanges(t, tt...)
+			expected := addresses(rr.Normalize())
+
+			it := iprange.RandomIterator(rr, 42)
+			assert.Equal(t, rr.Normalize().Count(), it.Count())
+
+			res := collect(it)
+			assert.ElementsMatch(t, expected, res)
+
+			if len(expected) > 10 {
+				assert.NotEqual(t, expected, res, "sequential order")
+			}
+
+			// Same seed, same order.
+			assert.Equal(t, res, collect(iprange.RandomIterator(rr, 42)))
+
+			// Reset, same order.
+			it.Reset()
+			assert.Equal(t, res, collect(it), "after reset")
+
+			// Other seed, other order.
+			if len(expected) > 10 {
+				assert.NotEqual(t, res, collect(iprange.RandomIterator(rr, 43)))
+			}
+		})
+	}
+}
+
+func TestRandomIteratorHuge(t *testing.T) {
+	r := iprange.Parse("2001:db8::/32")
+
+	it := iprange.RandomIterator(r, 1)
+	seen := make(map[netip.Addr]bool)
+
+	var addr netip.Addr
+	for i := 0; i < 10000 && iprange.NextAddr(it, &addr); i++ {
+		require.True(t, iprange.ContainsAddr(r, addr), addr.String())
+		require.False(t, seen[addr], addr.String())
+		seen[addr] = true
+	}
+	assert.Len(t, seen, 10000)
+}
+
+func TestRandomIteratorAll(t *testing.T) {
+	r := iprange.Ranges{iprange.Parse("::/0"), iprange.Parse("10.0.0.0/8")}
+
+	it := iprange.RandomIterator(r, 1)
+	assert.Equal(t, r.Count(), it.Count())
+
+	var ip net.IP
+	for i := 0; i < 100; i++ {
+		require.True(t, it.Next(&ip))
+		require.True(t, r.Contains(ip), ip.String())
+	}
+}
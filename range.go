@@ -2,8 +2,12 @@ package iprange
 
 import (
 	"bytes"
+	"fmt"
 	"math/big"
 	"net"
+	"net/netip"
+	"strconv"
+	"strings"
 )
 
 // Range is an interface to work IP-addresses range.
@@ -32,6 +36,11 @@ func (r singleRange) Contains(ip net.IP) bool {
 	return r.Equal(ip)
 }
 
+func (r singleRange) ContainsAddr(addr netip.Addr) bool {
+	ip, ok := netip.AddrFromSlice(r.IP)
+	return ok && ip.Unmap() == addr.Unmap()
+}
+
 func (r singleRange) Count() *big.Int {
 	return big.NewInt(1)
 }
@@ -50,16 +59,86 @@ type minMaxRange struct {
 
 var _ Range = minMaxRange{}
 
+// cidr2range returns range of addresses of CIDR ip/n, bits is the IP length in bits.
+func cidr2range(ip net.IP, n, bits int) *minMaxRange {
+	mask := net.CIDRMask(n, bits)
+	min := ip.Mask(mask)
+	max := make(net.IP, len(min))
+
+	for i, m := range mask {
+		if m == 0xff {
+			max[i] = min[i]
+		} else {
+			max[i] = min[i] | (m ^ 0xff)
+		}
+	}
+
+	return &minMaxRange{min, max}
+}
+
+// wildcard2range returns range of addresses matching ip with ACL wildcard mask,
+// bits set in the wildcard can have any value. Contiguous wildcards give CIDR ranges.
+func wildcard2range(ip, wildcard []uint16) Range {
+	top := octtop(len(ip))
+
+	netmask := make([]uint16, len(wildcard))
+	for i, w := range wildcard {
+		netmask[i] = w ^ top
+	}
+
+	if n, bits := net.IPMask(octets2ip(netmask)).Size(); bits != 0 {
+		return cidr2range(octets2ip(ip), n, bits)
+	}
+
+	octs := make(ipOctets, len(ip))
+	for i, w := range wildcard {
+		base := ip[i] &^ w
+
+		// Subsets of wildcard bits in increasing order.
+		for sub := uint16(0); ; sub = (sub - w) & w {
+			v := base | sub
+			if n := len(octs[i]); n > 0 && octs[i][n-1].hi+1 == v {
+				octs[i][n-1].hi = v
+			} else {
+				octs[i] = append(octs[i], ipOctet{v, v})
+			}
+
+			if sub == w {
+				break
+			}
+		}
+	}
+
+	return &octetsRange{octs}
+}
+
 func (r minMaxRange) Contains(ip net.IP) bool {
 	if ipv4 := ip.To4(); ipv4 != nil {
 		ip = ipv4
 	}
 
+	if len(ip) != len(r.min) {
+		return false
+	}
+
 	min := bytes.Compare(ip, r.min)
 	max := bytes.Compare(ip, r.max)
 	return min == 0 || max == 0 || (min == 1 && max == -1)
 }
 
+func (r minMaxRange) ContainsAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+
+	min, _ := netip.AddrFromSlice(r.min)
+	max, _ := netip.AddrFromSlice(r.max)
+
+	if addr.BitLen() != min.BitLen() {
+		return false
+	}
+
+	return addr.Compare(min) >= 0 && addr.Compare(max) <= 0
+}
+
 func (r minMaxRange) Count() *big.Int {
 	c := ip2big(r.max)
 	c.Sub(c, ip2big(r.min))
@@ -68,7 +147,33 @@ func (r minMaxRange) Count() *big.Int {
 }
 
 func (r minMaxRange) Iterator() Iterator {
-	return &minMaxIterator{r, ip2big(r.min), ip2big(r.max)}
+	return newMinMaxIterator(r, false)
+}
+
+// String returns the range in CIDR form if it is prefix-aligned, begin_end form otherwise.
+func (r minMaxRange) String() string {
+	if n := r.prefixLen(); n >= 0 {
+		return ip2string(r.min) + "/" + strconv.Itoa(n)
+	}
+	return ip2string(r.min) + "_" + ip2string(r.max)
+}
+
+// prefixLen returns length of the prefix if the range is a CIDR, -1 otherwise.
+func (r minMaxRange) prefixLen() int {
+	bits := 8 * len(r.min)
+
+	n := 0
+	for n < bits && ipbit(r.min, n) == ipbit(r.max, n) {
+		n++
+	}
+
+	for i := n; i < bits; i++ {
+		if ipbit(r.min, i) != 0 || ipbit(r.max, i) != 1 {
+			return -1
+		}
+	}
+
+	return n
 }
 
 //
@@ -82,8 +187,23 @@ type octetsRange struct {
 var _ Range = &octetsRange{}
 
 func (r octetsRange) Contains(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	return ok && r.ContainsAddr(addr)
+}
+
+func (r octetsRange) ContainsAddr(addr netip.Addr) bool {
+	octs, n := addr2octets(addr)
+	return r.contains(octs[:n])
+}
+
+// contains checks if the IP-address with the given octets is in the range.
+func (r octetsRange) contains(octs []uint16) bool {
+	if len(octs) != len(r.octets) {
+		return false
+	}
+
 	var contains bool
-	for i, oct := range ip2octets(ip) {
+	for i, oct := range octs {
 		contains = false
 		for _, b := range r.octets[i] {
 			if oct >= b.lo && oct <= b.hi {
@@ -104,7 +224,7 @@ func (r octetsRange) Count() *big.Int {
 	for i := len(r.octets) - 1; i >= 0; i-- {
 		s := big.NewInt(0)
 		for _, b := range r.octets[i] {
-			s.Add(s, big.NewInt(int64(b.hi-b.lo+1)))
+			s.Add(s, big.NewInt(int64(b.hi)-int64(b.lo)+1))
 		}
 		c.Mul(c, s)
 	}
@@ -113,9 +233,12 @@ func (r octetsRange) Count() *big.Int {
 }
 
 func (r octetsRange) Iterator() Iterator {
-	indexes := make([]int, len(r.octets))
-	min := r.octets.min()
-	return &octetsIterator{r, false, indexes, min}
+	return newOctetsIterator(r, false)
+}
+
+// String returns the range in octets form, e.g. "192.168.1,3-5.1-10".
+func (r octetsRange) String() string {
+	return r.octets.format()
 }
 
 //
@@ -137,7 +260,18 @@ func (rr Ranges) Contains(ip net.IP) bool {
 	return false
 }
 
+// ContainsAddr is like Contains, but takes netip.Addr.
+func (rr Ranges) ContainsAddr(addr netip.Addr) bool {
+	for _, r := range rr {
+		if ContainsAddr(r, addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // Count allows Ranges to satisfy Range interface.
+// Addresses which are in multiple ranges are counted multiple times, see Normalize.
 func (rr Ranges) Count() *big.Int {
 	c := big.NewInt(0)
 	for _, r := range rr {
@@ -146,6 +280,26 @@ func (rr Ranges) Count() *big.Int {
 	return c
 }
 
+// String returns ranges separated by spaces.
+func (rr Ranges) String() string {
+	ss := make([]string, len(rr))
+	for i, r := range rr {
+		ss[i] = fmt.Sprint(r)
+	}
+	return strings.Join(ss, " ")
+}
+
+// Normalize returns ranges which cover the same addresses as rr, but do not overlap.
+// Count of normalized ranges returns the exact number of addresses and
+// their Iterator yields every address only once.
+func (rr Ranges) Normalize() Ranges {
+	r := fromBoxes(disjoint(rr.boxes()))
+	if n, ok := r.(Ranges); ok {
+		return n
+	}
+	return Ranges{r}
+}
+
 // Iterator allows Ranges to satisfy Range interface.
 func (rr Ranges) Iterator() Iterator {
 	its := make([]Iterator, len(rr))
@@ -153,5 +307,5 @@ func (rr Ranges) Iterator() Iterator {
 		its[i] = rr[i].Iterator()
 	}
 
-	return &rangesIterator{its, 0}
+	return &rangesIterator{its: its}
 }
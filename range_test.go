@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/big"
 	"net"
+	"net/netip"
 	"strings"
 	"testing"
 
@@ -53,6 +54,8 @@ func TestContains(t *testing.T) {
 		// cidr
 		{[]string{"2001:db8::/48"}, "2001:db8::10", true},
 		{[]string{"2001:db8::/48"}, "2001:ab8::", false},
+		{[]string{"1:2:3:4::abab:dead/120"}, "1:2:3:4::abab:de00", true},
+		{[]string{"1:2:3:4::abab:dead/120"}, "1:2:3:4::de00", false},
 
 		// begin_end
 		{[]string{"2001:db8::_2001:db8::10"}, "2001:db8::5", true},
@@ -74,6 +77,9 @@ func TestContains(t *testing.T) {
 
 			contains := rr.Contains(net.ParseIP(tt.ip))
 			assert.Equal(t, tt.contains, contains)
+
+			contains = rr.ContainsAddr(netip.MustParseAddr(tt.ip))
+			assert.Equal(t, tt.contains, contains, "addr")
 		})
 	}
 }
@@ -118,6 +124,7 @@ func TestCount(t *testing.T) {
 
 		// octets
 		{[]string{"2001:DB8:3C4D:7777::123-130"}, 14},
+		{[]string{"2001:DB8:3C4D:7777::0-ffff"}, 65536},
 	}
 
 	for i, tt := range tests {
@@ -133,3 +140,123 @@ func TestCount(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		ss    []string
+		count int64
+	}{
+		// Disjoint
+		{[]string{"10.0.0.1", "10.0.0.3"}, 2},
+
+		// Nested
+		{[]string{"10.0.0.0/24", "10.0.0.0/16"}, 65536},
+		{[]string{"10.0.0.0/24", "10.0.0.10_10.0.0.20", "10.0.0.15"}, 256},
+
+		// Overlapping
+		{[]string{"10.0.0.0_10.0.0.10", "10.0.0.5_10.0.0.15"}, 16},
+
+		// Octets and CIDR
+		{[]string{"10.0.0-3.1-10", "10.0.1.0/24"}, 286},
+		{[]string{"10.0.0-3.1-10", "10.0.2-5.5-20"}, 92},
+
+		// Overlapping octet bounds
+		{[]string{"10.0.0.1-5,3-7"}, 7},
+
+		// IPv6
+		{[]string{"2001:db8::/120", "2001:db8::0-1:0-ff"}, 512},
+
+		// Mixed
+		{[]string{"10.0.0.0/30", "10.0.0.1", "2001:db8::/126", "2001:db8::1"}, 8},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, strings.Join(tt.ss, ",")), func(t *testing.T) {
+			rr := make(iprange.Ranges, 0)
+			for _, s := range tt.ss {
+				r := iprange.Parse(s)
+				require.NotNil(t, r)
+				rr = append(rr, r)
+			}
+
+			n := rr.Normalize()
+			assert.Equal(t, big.NewInt(tt.count), n.Count())
+
+			it := n.Iterator()
+			seen := make(map[string]bool)
+			var ip net.IP
+			for it.Next(&ip) {
+				assert.False(t, seen[ip.String()], "duplicate %s", ip)
+				assert.True(t, rr.Contains(ip))
+				seen[ip.String()] = true
+			}
+			assert.EqualValues(t, tt.count, len(seen))
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		s   string
+		res string
+	}{
+		//
+		// IPv4
+		//
+
+		// single
+		{"192.168.1.1", "192.168.1.1"},
+
+		// cidr
+		{"192.168.1.10/24", "192.168.1.0/24"},
+		{"0.0.0.0/0", "0.0.0.0/0"},
+
+		// begin_end
+		{"192.168.1.10_192.168.2.9", "192.168.1.10_192.168.2.9"},
+		{"192.168.1.0_192.168.1.255", "192.168.1.0/24"},
+		{"192.168.1.1_192.168.1.1", "192.168.1.1/32"},
+
+		// octets
+		{"192.168.1,3-5.1-10", "192.168.1,3-5.1-10"},
+		{"192.168.5-7,1,3.1-5,4-10", "192.168.1,3,5-7.1-10"},
+
+		//
+		// IPv6
+		//
+
+		// single
+		{"2001:0db8::0001", "2001:db8::1"},
+
+		// cidr
+		{"2001:db8::1/32", "2001:db8::/32"},
+		{"::ffff:0:0/96", "::ffff:0:0/96"},
+
+		// begin_end
+		{"2001:db8::1_2001:db8::10", "2001:db8::1_2001:db8::10"},
+
+		// octets
+		{"2001:db8:0:0:0:0:1-10:0", "2001:db8::1-10:0"},
+		{"2001:db8:0:0:1-2:0:0:0", "2001:db8:0:0:1-2::"},
+		{"0:0:1,2::", "0:0:1-2::"},
+		{"0-ffff:0:1::", "0-ffff:0:1::"},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s", i, tt.s), func(t *testing.T) {
+			r := iprange.Parse(tt.s)
+			require.NotNil(t, r)
+			assert.Equal(t, tt.res, fmt.Sprint(r))
+
+			// Round trip.
+			rt := iprange.Parse(fmt.Sprint(r))
+			require.NotNil(t, rt)
+			assert.Equal(t, r, rt)
+			assert.Equal(t, r.Count(), rt.Count())
+		})
+	}
+}
+
+func TestRangesString(t *testing.T) {
+	rr := iprange.Ranges{iprange.Parse("10.0.0.1"), iprange.Parse("10.0.0.0/24"), iprange.Parse("2001:db8::1-2")}
+	assert.Equal(t, "10.0.0.1 10.0.0.0/24 2001:db8::1-2", rr.String())
+}
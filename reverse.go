@@ -0,0 +1,45 @@
+package iprange
+
+// ReverseIterator returns iterator which yields addresses of the range
+// in the reverse order of its Iterator, i.e. from the highest address of every range.
+// Members of Ranges are visited from the last to the first.
+// Ranges implemented outside of the package are iterated from their highest address.
+func ReverseIterator(r Range) Iterator {
+	if rv, ok := r.(reverser); ok {
+		return rv.reverseIterator()
+	}
+	return ReverseIterator(fromBoxes(disjoint(boxesOf(r))))
+}
+
+// reverser is implemented by ranges which can be iterated in reverse order.
+type reverser interface {
+	reverseIterator() Iterator
+}
+
+var (
+	_ reverser = singleRange{}
+	_ reverser = minMaxRange{}
+	_ reverser = octetsRange{}
+	_ reverser = Ranges{}
+)
+
+func (r singleRange) reverseIterator() Iterator {
+	return r.Iterator()
+}
+
+func (r minMaxRange) reverseIterator() Iterator {
+	return newMinMaxIterator(r, true)
+}
+
+func (r octetsRange) reverseIterator() Iterator {
+	return newOctetsIterator(r, true)
+}
+
+func (rr Ranges) reverseIterator() Iterator {
+	its := make([]Iterator, len(rr))
+	for i := 0; i < len(rr); i++ {
+		its[i] = ReverseIterator(rr[len(rr)-1-i])
+	}
+
+	return &rangesIterator{its: its, reverse: true}
+}
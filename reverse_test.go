@@ -0,0 +1,67 @@
+package iprange_test
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/russtone/iprange"
+)
+
+func TestReverseIterator(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.250_10.0.1.5"},
+		{"10.0.0.0/30"},
+		{"10.0.1-3,7.1-3,20-22"},
+		{"10.0.254-255.0-1,254-255"},
+		{"2001:db8::1-2:fffe-ffff,1-2"},
+		{"2001:db8::fffe_2001:db8::1:1"},
+		{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/126"},
+		{},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt, ","), func(t *testing.T) {
+			rr := parseRanges(t, tt...)
+
+			expected := collect(rr.Iterator())
+			for i, j := 0, len(expected)-1; i < j; i, j = i+1, j-1 {
+				expected[i], expected[j] = expected[j], expected[i]
+			}
+
+			it := iprange.ReverseIterator(rr)
+			assert.Equal(t, expected, collect(it))
+			assert.Equal(t, rr.Count(), it.Count())
+
+			it.Reset()
+			assert.Equal(t, expected, collect(it), "after reset")
+
+			if len(tt) == 1 {
+				assert.Equal(t, expected, collect(iprange.ReverseIterator(rr[0])), "single range")
+			}
+		})
+	}
+}
+
+func TestReverseIteratorFullGroup(t *testing.T) {
+	it := iprange.ReverseIterator(iprange.Parse("2001:db8::1:0-ffff"))
+
+	var ip net.IP
+	assert.True(t, it.Next(&ip))
+	assert.Equal(t, "2001:db8::1:ffff", ip.String())
+
+	assert.Equal(t, int64(0x10000), it.Count().Int64())
+	assert.Len(t, collect(it), 0xffff)
+}
+
+func TestReverseIteratorHighest(t *testing.T) {
+	r := iprange.Ranges{iprange.Parse("10.0.0.0/8"), iprange.Parse("::/0")}
+	it := iprange.ReverseIterator(r)
+
+	var ip net.IP
+	assert.True(t, it.Next(&ip))
+	assert.Equal(t, "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", ip.String())
+}
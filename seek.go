@@ -0,0 +1,304 @@
+package iprange
+
+import (
+	"math/big"
+	"net"
+	"net/netip"
+)
+
+// SeekIterator is implemented by iterators which can move to an address without iterating
+// the addresses before it. Iterators of the package implement it.
+type SeekIterator interface {
+	Iterator
+
+	// Skip moves the iterator n addresses forward without iterating them.
+	Skip(n *big.Int)
+
+	// SeekTo moves the iterator to the given IP-address, so it is returned by the next call of Next.
+	// Returns false and leaves the iterator unchanged if the iterator does not contain the address.
+	SeekTo(net.IP) bool
+}
+
+var (
+	_ SeekIterator = &singleIterator{}
+	_ SeekIterator = &minMaxIterator{}
+	_ SeekIterator = &octetsIterator{}
+	_ SeekIterator = &rangesIterator{}
+	_ SeekIterator = &randomIterator{}
+)
+
+// Skip moves the iterator n addresses forward without iterating them.
+// Iterators which do not implement SeekIterator are moved forward with Next.
+func Skip(it Iterator, n *big.Int) {
+	if s, ok := it.(SeekIterator); ok {
+		s.Skip(n)
+		return
+	}
+
+	var ip net.IP
+	one := big.NewInt(1)
+	for i := new(big.Int).Set(n); i.Sign() > 0; i.Sub(i, one) {
+		if !NextInto(it, &ip) {
+			return
+		}
+	}
+}
+
+// SeekTo moves the iterator to the given IP-address, so it is returned by the next call of Next.
+// Returns false and leaves the iterator unchanged if the iterator does not contain the address
+// or does not implement SeekIterator.
+func SeekTo(it Iterator, ip net.IP) bool {
+	if s, ok := it.(SeekIterator); ok {
+		return s.SeekTo(ip)
+	}
+	return false
+}
+
+// seeker is implemented by iterators which can report and change their position,
+// i.e. index of the next address.
+type seeker interface {
+	// position returns index of the next address, number of addresses if there are none left.
+	position() *big.Int
+
+	// seek moves the iterator to the address with the given index,
+	// iterator is exhausted if the index is not less than the number of addresses.
+	seek(i *big.Int)
+}
+
+var (
+	_ seeker = &singleIterator{}
+	_ seeker = &minMaxIterator{}
+	_ seeker = &octetsIterator{}
+	_ seeker = &rangesIterator{}
+	_ seeker = &randomIterator{}
+)
+
+// skip moves the iterator n addresses forward, or backward if n is negative.
+func skip(s seeker, n *big.Int) {
+	i := s.position()
+	i.Add(i, n)
+	if i.Sign() < 0 {
+		i.SetInt64(0)
+	}
+	s.seek(i)
+}
+
+//
+// Single IP iterator.
+//
+
+func (it *singleIterator) Skip(n *big.Int) {
+	skip(it, n)
+}
+
+func (it *singleIterator) SeekTo(ip net.IP) bool {
+	if !it.Contains(ip) {
+		return false
+	}
+	it.done = false
+	return true
+}
+
+func (it *singleIterator) position() *big.Int {
+	if it.done {
+		return big.NewInt(1)
+	}
+	return big.NewInt(0)
+}
+
+func (it *singleIterator) seek(i *big.Int) {
+	it.done = i.Sign() > 0
+}
+
+//
+// Min max range iterator.
+//
+
+func (it *minMaxIterator) Skip(n *big.Int) {
+	skip(it, n)
+}
+
+func (it *minMaxIterator) SeekTo(ip net.IP) bool {
+	addr, _ := netip.AddrFromSlice(ip)
+	if !it.ContainsAddr(addr) {
+		return false
+	}
+	it.current = u128FromAddr(addr)
+	it.done = false
+	return true
+}
+
+func (it *minMaxIterator) position() *big.Int {
+	if it.done {
+		return it.Count()
+	}
+	if it.reverse {
+		return it.first.sub(it.current).big()
+	}
+	return it.current.sub(it.first).big()
+}
+
+func (it *minMaxIterator) seek(i *big.Int) {
+	j, ok := u128FromBig(i)
+	if !ok || j.cmp(it.lastIndex()) == 1 {
+		it.done = true
+		return
+	}
+	it.seekIndex(j)
+}
+
+// seekIndex moves the iterator to the address with the given index in the iteration order,
+// index must not be greater than the last one.
+func (it *minMaxIterator) seekIndex(i uint128) {
+	if it.reverse {
+		it.current = it.first.sub(i)
+	} else {
+		it.current = it.first.add(i)
+	}
+	it.done = false
+}
+
+//
+// Octets range iterator.
+//
+
+func (it *octetsIterator) Skip(n *big.Int) {
+	skip(it, n)
+}
+
+func (it *octetsIterator) SeekTo(ip net.IP) bool {
+	addr, _ := netip.AddrFromSlice(ip)
+	i, ok := it.indexOf(addr)
+	if ok {
+		it.seekRangeIndex(i)
+	}
+	return ok
+}
+
+func (it *octetsIterator) position() *big.Int {
+	if it.done {
+		return it.Count()
+	}
+	i, _ := it.indexOf(octets2addr(it.current))
+	if it.reverse {
+		i = it.lastIndex().sub(i)
+	}
+	return i.big()
+}
+
+func (it *octetsIterator) seek(i *big.Int) {
+	j, ok := u128FromBig(i)
+	if !ok || j.cmp(it.lastIndex()) == 1 {
+		it.done = true
+		return
+	}
+	it.seekIndex(j)
+}
+
+// seekIndex moves the iterator to the address with the given index in the iteration order,
+// index must not be greater than the last one.
+func (it *octetsIterator) seekIndex(i uint128) {
+	if it.reverse {
+		i = it.lastIndex().sub(i)
+	}
+	it.seekRangeIndex(i)
+}
+
+// seekRangeIndex moves the iterator to the address with the given index in the range.
+func (it *octetsIterator) seekRangeIndex(i uint128) {
+	for k := len(it.octets) - 1; k >= 0; k-- {
+		var d uint64
+		i, d = i.divmod64(boundsSize(it.octets[k]))
+		it.indexes[k], it.current[k] = boundsLocate(it.octets[k], d)
+	}
+	it.done = false
+}
+
+//
+// Multiple ranges iterator.
+//
+
+func (it *rangesIterator) Skip(n *big.Int) {
+	skip(it, n)
+}
+
+// SeekTo moves the iterator to the first occurrence of the address.
+func (it *rangesIterator) SeekTo(ip net.IP) bool {
+	for k, i := range it.its {
+		if i.Contains(ip) {
+			s, ok := i.(SeekIterator)
+			if !ok {
+				return false
+			}
+			it.Reset()
+			it.idx = k
+			return s.SeekTo(ip)
+		}
+	}
+	return false
+}
+
+// Iterators of ranges implemented outside of the package cannot report their position,
+// they are resumed from their first address.
+func (it *rangesIterator) position() *big.Int {
+	pos := big.NewInt(0)
+	for k := 0; k < it.idx && k < len(it.its); k++ {
+		pos.Add(pos, it.its[k].Count())
+	}
+	if it.idx < len(it.its) {
+		if s, ok := it.its[it.idx].(seeker); ok {
+			pos.Add(pos, s.position())
+		}
+	}
+	return pos
+}
+
+func (it *rangesIterator) seek(i *big.Int) {
+	it.Reset()
+
+	rest := new(big.Int).Set(i)
+	for ; it.idx < len(it.its); it.idx++ {
+		c := it.its[it.idx].Count()
+		if rest.Cmp(c) < 0 {
+			Skip(it.its[it.idx], rest)
+			return
+		}
+		rest.Sub(rest, c)
+	}
+}
+
+//
+// Random order iterator.
+//
+
+func (it *randomIterator) Skip(n *big.Int) {
+	skip(it, n)
+}
+
+func (it *randomIterator) SeekTo(ip net.IP) bool {
+	addr, _ := netip.AddrFromSlice(ip)
+	i, ok := it.indexed.indexOf(addr)
+	if ok {
+		it.current = it.perm.unpermute(i)
+		it.done = false
+	}
+	return ok
+}
+
+func (it *randomIterator) position() *big.Int {
+	if it.done {
+		return it.Count()
+	}
+	return it.current.big()
+}
+
+func (it *randomIterator) seek(i *big.Int) {
+	j, ok := u128FromBig(i)
+	if it.indexed.empty() || !ok || j.cmp(it.indexed.last) == 1 {
+		it.done = true
+		return
+	}
+
+	it.current = j
+	it.done = false
+}
@@ -0,0 +1,144 @@
+package iprange_test
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+var seekTests = [][]string{
+	{"10.0.0.1"},
+	{"10.0.0.250_10.0.1.5"},
+	{"10.0.1-3,7.1-3,20-22"},
+	{"2001:db8::1-2:fffe-ffff,1-2"},
+	{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/126"},
+}
+
+var seekIterators = []struct {
+	name string
+	new  func(r iprange.Range) iprange.Iterator
+}{
+	{"sequential", func(r iprange.Range) iprange.Iterator { return r.Iterator() }},
+	{"random", func(r iprange.Range) iprange.Iterator { return iprange.RandomIterator(r, 7) }},
+	{"reverse", iprange.ReverseIterator},
+}
+
+func TestSkip(t *testing.T) {
+	for i, tt := range seekTests {
+		for _, itt := range seekIterators {
+			t.Run(fmt.Sprintf("%d/%s/%s", i, itt.name, strings.Join(tt, ",")), func(t *testing.T) {
+				rr := parseRanges(t, tt...)
+				expected := collect(itt.new(rr))
+
+				for n := 0; n <= len(expected)+1; n++ {
+					it := itt.new(rr)
+					iprange.Skip(it, big.NewInt(int64(n)))
+
+					if n > len(expected) {
+						assert.Empty(t, collect(it), "skipped %d", n)
+						continue
+					}
+					assert.Equal(t, expected[n:], collect(it), "skipped %d", n)
+				}
+			})
+		}
+	}
+}
+
+func TestSkipPartial(t *testing.T) {
+	for i, tt := range seekTests {
+		for _, itt := range seekIterators {
+			t.Run(fmt.Sprintf("%d/%s/%s", i, itt.name, strings.Join(tt, ",")), func(t *testing.T) {
+				rr := parseRanges(t, tt...)
+				expected := collect(itt.new(rr))
+
+				for n := 0; n < len(expected); n++ {
+					it := itt.new(rr)
+
+					var ip net.IP
+					for j := 0; j < n; j++ {
+						require.True(t, it.Next(&ip))
+					}
+
+					iprange.Skip(it, big.NewInt(1))
+					assert.Equal(t, expected[n+1:], collect(it), "read %d, skipped 1", n)
+				}
+			})
+		}
+	}
+}
+
+func TestSeekTo(t *testing.T) {
+	for i, tt := range seekTests {
+		for _, itt := range seekIterators {
+			t.Run(fmt.Sprintf("%d/%s/%s", i, itt.name, strings.Join(tt, ",")), func(t *testing.T) {
+				rr := parseRanges(t, tt...)
+				expected := collect(itt.new(rr))
+
+				for _, s := range expected {
+					it := itt.new(rr)
+					require.True(t, iprange.SeekTo(it, net.ParseIP(s)), s)
+
+					// First occurrence of the address.
+					n := 0
+					for expected[n] != s {
+						n++
+					}
+					assert.Equal(t, expected[n:], collect(it), "seek to %s", s)
+				}
+			})
+		}
+	}
+}
+
+func TestSeekToMissing(t *testing.T) {
+	for _, itt := range seekIterators {
+		t.Run(itt.name, func(t *testing.T) {
+			rr := parseRanges(t, "10.0.0.1", "10.0.0.4_10.0.0.6", "10.0.1,3.1", "2001:db8::/127")
+			expected := collect(itt.new(rr))
+
+			for _, s := range []string{"10.0.0.2", "10.0.0.7", "10.0.2.1", "2001:db8::2", "::ffff:10.0.2.1"} {
+				it := itt.new(rr)
+
+				var ip net.IP
+				require.True(t, it.Next(&ip))
+
+				assert.False(t, iprange.SeekTo(it, net.ParseIP(s)), s)
+				assert.Equal(t, expected[1:], collect(it), "seek to %s", s)
+			}
+		})
+	}
+}
+
+func TestSkipHuge(t *testing.T) {
+	it := iprange.Ranges{iprange.Parse("10.0.0.0/30"), iprange.Parse("::/0"), iprange.Parse("10.0.1.0/30")}.Iterator()
+
+	n := new(big.Int).Lsh(big.NewInt(1), 128)
+	n.Add(n, big.NewInt(2))
+	iprange.Skip(it, n)
+
+	// 4 addresses of the first range, then the whole IPv6 space except the last 2 addresses.
+	assert.Equal(t, []string{"ffff:ffff:ffff:ffff:ffff:ffff:ffff:fffe", "ffff:ffff:ffff:ffff:ffff:ffff:ffff:ffff", "10.0.1.0", "10.0.1.1", "10.0.1.2", "10.0.1.3"}, collect(it))
+}
+
+func TestSeekToHuge(t *testing.T) {
+	for _, itt := range seekIterators {
+		t.Run(itt.name, func(t *testing.T) {
+			it := itt.new(iprange.Parse("2001:db8::/32"))
+
+			ip := net.ParseIP("2001:db8:1234::5678")
+			require.True(t, iprange.SeekTo(it, ip))
+
+			var next net.IP
+			require.True(t, it.Next(&next))
+			assert.Equal(t, ip.String(), next.String())
+		})
+	}
+}
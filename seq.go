@@ -0,0 +1,51 @@
+package iprange
+
+import (
+	"iter"
+	"math/big"
+	"net"
+	"net/netip"
+)
+
+// All returns sequence of IP-addresses of the range in the order of its Iterator,
+// so the range can be used in for-range loops. Every iteration starts from the first address.
+func All(r Range) iter.Seq[net.IP] {
+	return func(yield func(net.IP) bool) {
+		it := r.Iterator()
+		var ip net.IP
+		for it.Next(&ip) {
+			if !yield(ip) {
+				return
+			}
+		}
+	}
+}
+
+// AllAddrs is like All, but yields addresses as netip.Addr.
+func AllAddrs(r Range) iter.Seq[netip.Addr] {
+	return func(yield func(netip.Addr) bool) {
+		it := r.Iterator()
+		var addr netip.Addr
+		for NextAddr(it, &addr) {
+			if !yield(addr) {
+				return
+			}
+		}
+	}
+}
+
+// AllIndexed is like All, but also yields index of every address, see At.
+func AllIndexed(r Range) iter.Seq2[*big.Int, net.IP] {
+	return func(yield func(*big.Int, net.IP) bool) {
+		it := r.Iterator()
+		i := big.NewInt(0)
+		one := big.NewInt(1)
+		var ip net.IP
+		for it.Next(&ip) {
+			if !yield(new(big.Int).Set(i), ip) {
+				return
+			}
+			i.Add(i, one)
+		}
+	}
+}
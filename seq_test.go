@@ -0,0 +1,96 @@
+package iprange_test
+
+import (
+	"maps"
+	"math/big"
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/russtone/iprange"
+)
+
+func TestAll(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.250_10.0.1.5"},
+		{"10.0.1-3,7.1-3,20-22"},
+		{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/126"},
+		{},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt, ","), func(t *testing.T) {
+			rr := parseRanges(t, tt...)
+			expected := collect(rr.Iterator())
+
+			ips := slices.Collect(iprange.All(rr))
+			assert.Equal(t, expected, ips2strings(ips))
+
+			// Every iteration starts from the first address.
+			assert.Equal(t, expected, ips2strings(slices.Collect(iprange.All(rr))), "second iteration")
+
+			addrs := make([]string, 0)
+			for addr := range iprange.AllAddrs(rr) {
+				addrs = append(addrs, addr.String())
+			}
+			assert.Equal(t, expected, addrs)
+
+			indexed := maps.Collect(iprange.AllIndexed(rr))
+			assert.Len(t, indexed, len(expected))
+			for i, ip := range indexed {
+				j, ok := iprange.IndexOf(rr, ip)
+				assert.True(t, ok)
+				assert.LessOrEqual(t, j.Cmp(i), 0, "%s at %s", ip, i)
+
+				at, ok := iprange.At(rr, i)
+				assert.True(t, ok)
+				assert.Equal(t, at.String(), ip.String())
+			}
+		})
+	}
+}
+
+func TestAllBreak(t *testing.T) {
+	r := iprange.Parse("::/0")
+
+	ips := make([]string, 0)
+	for ip := range iprange.All(r) {
+		if len(ips) == 3 {
+			break
+		}
+		ips = append(ips, ip.String())
+	}
+	assert.Equal(t, []string{"::", "::1", "::2"}, ips)
+
+	var last netip.Addr
+	for addr := range iprange.AllAddrs(r) {
+		last = addr
+		if addr == netip.MustParseAddr("::5") {
+			break
+		}
+	}
+	assert.Equal(t, "::5", last.String())
+
+	var index *big.Int
+	for i, ip := range iprange.AllIndexed(r) {
+		index = i
+		if ip.Equal(net.ParseIP("::a")) {
+			break
+		}
+	}
+	assert.Equal(t, int64(10), index.Int64())
+}
+
+// ips2strings returns IP-addresses as strings.
+func ips2strings(ips []net.IP) []string {
+	res := make([]string, 0, len(ips))
+	for _, ip := range ips {
+		res = append(res, ip.String())
+	}
+	return res
+}
@@ -0,0 +1,500 @@
+package iprange
+
+import (
+	"net"
+	"sort"
+)
+
+// Union returns a range of IP-addresses which are in a or in b.
+func Union(a, b Range) Range {
+	return fromBoxes(disjoint(append(boxesOf(a), boxesOf(b)...)))
+}
+
+// Intersect returns a range of IP-addresses which are both in a and in b.
+func Intersect(a, b Range) Range {
+	res := make([]ipOctets, 0)
+	idx := newBoxIndexes(boxesOf(b))
+	for _, x := range boxesOf(a) {
+		for _, y := range idx[boxFamily(x)].overlapping(boxBounds(x)) {
+			if box := x.intersect(y.box); box != nil {
+				res = append(res, box)
+			}
+		}
+	}
+	return fromBoxes(disjoint(res))
+}
+
+// Subtract returns a range of IP-addresses which are in a, but not in b.
+func Subtract(a, b Range) Range {
+	return fromBoxes(subtractBoxes(disjoint(boxesOf(a)), boxesOf(b)))
+}
+
+//
+// Boxes
+//
+
+// boxer is implemented by ranges which can be represented as a union of boxes.
+// Box is an ipOctets with normalized bounds, every IP-address in a box
+// is a combination of octets from its bounds.
+// Boxes of one range may overlap.
+type boxer interface {
+	boxes() []ipOctets
+}
+
+func (r singleRange) boxes() []ipOctets {
+	octs := ip2octets(r.IP)
+	box := make(ipOctets, len(octs))
+	for i, oct := range octs {
+		box[i] = []ipOctet{{oct, oct}}
+	}
+	return []ipOctets{box}
+}
+
+func (r minMaxRange) boxes() []ipOctets {
+	return interval2boxes(bytes2octets(r.min), bytes2octets(r.max), octtops(len(r.min)))
+}
+
+func (r octetsRange) boxes() []ipOctets {
+	return []ipOctets{r.octets.normalized()}
+}
+
+func (rr Ranges) boxes() []ipOctets {
+	res := make([]ipOctets, 0, len(rr))
+	for _, r := range rr {
+		res = append(res, boxesOf(r)...)
+	}
+	return res
+}
+
+// boxesOf returns boxes of any range.
+// Ranges implemented outside of the package are enumerated address by address.
+func boxesOf(r Range) []ipOctets {
+	if b, ok := r.(boxer); ok {
+		return b.boxes()
+	}
+
+	res := make([]ipOctets, 0)
+	it := r.Iterator()
+	var ip net.IP
+	for it.Next(&ip) {
+		res = append(res, singleRange{ip}.boxes()...)
+	}
+	return res
+}
+
+// disjoint returns boxes which cover the same addresses as bb, but do not overlap.
+// Boxes which are intervals are merged in one sorted sweep, the other boxes
+// are cut by the intervals and the previous boxes overlapping them in another one.
+func disjoint(bb []ipOctets) []ipOctets {
+	var spans [2][]span
+	other := make([]ipOctets, 0)
+	for _, b := range bb {
+		if b.isInterval() {
+			f := boxFamily(b)
+			spans[f] = append(spans[f], boxBounds(b))
+		} else {
+			other = append(other, b)
+		}
+	}
+
+	res := make([]ipOctets, 0, len(bb))
+	for f, ss := range spans {
+		for _, sp := range mergeSpans(ss) {
+			res = append(res, spanBoxes(sp, familyIPLen[f])...)
+		}
+	}
+
+	if len(other) == 0 {
+		return res
+	}
+
+	// Sweep the other boxes by the lowest address, keeping the previous pieces
+	// which can overlap next boxes.
+	idx := newBoxIndexes(other)
+	intervals := newBoxIndexes(res)
+	for _, x := range idx {
+		active := make([]indexedBox, 0)
+		for _, b := range x.boxes {
+			kept := active[:0]
+			for _, a := range active {
+				if a.bounds.hi.cmp(b.bounds.lo) >= 0 {
+					kept = append(kept, a)
+				}
+			}
+			active = kept
+
+			pieces := subtractIndexed([]ipOctets{b.box}, intervals)
+			for _, a := range active {
+				if a.bounds.overlaps(b.bounds) {
+					pieces = subtractBox(pieces, a.box)
+				}
+			}
+
+			for _, p := range pieces {
+				res = append(res, p)
+				active = append(active, indexedBox{p, boxBounds(p), false})
+			}
+		}
+	}
+
+	return res
+}
+
+// subtractBoxes returns disjoint boxes of addresses which are in bb, but not in other.
+// Boxes bb must be disjoint.
+func subtractBoxes(bb, other []ipOctets) []ipOctets {
+	return subtractIndexed(bb, newBoxIndexes(other))
+}
+
+// subtractIndexed is like subtractBoxes, but takes indexes of other boxes.
+// Intervals are subtracted from intervals in one sorted sweep.
+func subtractIndexed(bb []ipOctets, idx [2]*boxIndex) []ipOctets {
+	res := make([]ipOctets, 0, len(bb))
+	for _, b := range bb {
+		f, bounds := boxFamily(b), boxBounds(b)
+		other := idx[f].overlapping(bounds)
+
+		if b.isInterval() && allIntervals(other) {
+			res = append(res, subtractSpans(bounds, other, familyIPLen[f])...)
+			continue
+		}
+
+		pieces := []ipOctets{b}
+		for _, o := range other {
+			if pieces = subtractBox(pieces, o.box); len(pieces) == 0 {
+				break
+			}
+		}
+		res = append(res, pieces...)
+	}
+	return res
+}
+
+// subtractSpans returns disjoint boxes of addresses of the span which are not in bounds
+// of the intervals, iplen is the IP length in bytes. Intervals must be sorted by the lowest address.
+func subtractSpans(sp span, intervals []indexedBox, iplen int) []ipOctets {
+	res := make([]ipOctets, 0)
+	lo := sp.lo
+	for _, o := range intervals {
+		if o.bounds.lo.cmp(lo) > 0 {
+			hi := o.bounds.lo.sub64(1)
+			if hi.cmp(sp.hi) > 0 {
+				hi = sp.hi
+			}
+			res = append(res, spanBoxes(span{lo, hi}, iplen)...)
+		}
+		if o.bounds.hi.cmp(lo) >= 0 {
+			if o.bounds.hi.cmp(sp.hi) >= 0 {
+				return res
+			}
+			lo = o.bounds.hi.add64(1)
+		}
+	}
+	return append(res, spanBoxes(span{lo, sp.hi}, iplen)...)
+}
+
+// allIntervals checks if all the boxes are intervals.
+func allIntervals(bb []indexedBox) bool {
+	for _, b := range bb {
+		if !b.interval {
+			return false
+		}
+	}
+	return true
+}
+
+// subtractBox returns disjoint boxes of addresses which are in bb, but not in o.
+func subtractBox(bb []ipOctets, o ipOctets) []ipOctets {
+	res := make([]ipOctets, 0, len(bb))
+	for _, b := range bb {
+		res = append(res, b.subtract(o)...)
+	}
+	return res
+}
+
+// IP lengths in bytes of boxes families.
+var familyIPLen = [2]int{net.IPv4len, net.IPv6len}
+
+// boxFamily returns 0 for IPv4 boxes and 1 for IPv6 ones.
+func boxFamily(b ipOctets) int {
+	if len(b) == net.IPv4len {
+		return 0
+	}
+	return 1
+}
+
+// boxBounds returns span from the lowest to the highest address of the box.
+func boxBounds(b ipOctets) span {
+	return span{u128FromIP(octets2ip(b.min())), u128FromIP(octets2ip(b.max()))}
+}
+
+// spanBoxes returns disjoint boxes of addresses of the span, iplen is the IP length in bytes.
+func spanBoxes(sp span, iplen int) []ipOctets {
+	lo, hi := make(net.IP, iplen), make(net.IP, iplen)
+	sp.lo.putIP(lo)
+	sp.hi.putIP(hi)
+	return interval2boxes(bytes2octets(lo), bytes2octets(hi), octtops(iplen))
+}
+
+// boxIndex finds boxes of one family which can overlap a span by their bounds.
+type boxIndex struct {
+	boxes []indexedBox // sorted by the lowest address
+	maxHi []uint128    // maxHi[i] is the highest address of boxes[:i+1]
+}
+
+// indexedBox is a box with its bounds.
+type indexedBox struct {
+	box      ipOctets
+	bounds   span
+	interval bool
+}
+
+// newBoxIndexes returns indexes of IPv4 and IPv6 boxes.
+func newBoxIndexes(bb []ipOctets) [2]*boxIndex {
+	var idx [2]*boxIndex
+	for f := range idx {
+		idx[f] = &boxIndex{}
+	}
+
+	for _, b := range bb {
+		x := idx[boxFamily(b)]
+		x.boxes = append(x.boxes, indexedBox{b, boxBounds(b), b.isInterval()})
+	}
+
+	for _, x := range idx {
+		sort.Slice(x.boxes, func(i, j int) bool {
+			return x.boxes[i].bounds.lo.cmp(x.boxes[j].bounds.lo) < 0
+		})
+
+		x.maxHi = make([]uint128, len(x.boxes))
+		for i, b := range x.boxes {
+			x.maxHi[i] = b.bounds.hi
+			if i > 0 && x.maxHi[i-1].cmp(b.bounds.hi) > 0 {
+				x.maxHi[i] = x.maxHi[i-1]
+			}
+		}
+	}
+
+	return idx
+}
+
+// overlapping returns boxes which bounds overlap the span, sorted by the lowest address.
+func (x *boxIndex) overlapping(sp span) []indexedBox {
+	// Boxes before i end before the span, boxes from j start after it.
+	i := sort.Search(len(x.maxHi), func(i int) bool { return x.maxHi[i].cmp(sp.lo) >= 0 })
+	j := sort.Search(len(x.boxes), func(j int) bool { return x.boxes[j].bounds.lo.cmp(sp.hi) > 0 })
+
+	res := make([]indexedBox, 0)
+	for ; i < j; i++ {
+		if x.boxes[i].bounds.overlaps(sp) {
+			res = append(res, x.boxes[i])
+		}
+	}
+	return res
+}
+
+// interval2boxes returns disjoint boxes of addresses between lo and hi inclusive,
+// tops are the biggest values of every octet. Boxes are sorted.
+func interval2boxes(lo, hi, tops []uint16) []ipOctets {
+	n := len(lo)
+
+	// First octet which differs.
+	d := 0
+	for d < n && lo[d] == hi[d] {
+		d++
+	}
+
+	if d == n {
+		return []ipOctets{newBox(lo, n, 0, 0, tops)}
+	}
+
+	res := make([]ipOctets, 0)
+	from, to := int(lo[d]), int(hi[d])
+
+	// Addresses from lo to lo[:d+1].top.top...
+	if !octeq(lo[d+1:], make([]uint16, n-d-1)) {
+		z := n - 1
+		for z > d+1 && lo[z] == 0 {
+			z--
+		}
+		res = append(res, newBox(lo, z, lo[z], tops[z], tops))
+		for j := z - 1; j > d; j-- {
+			if lo[j] < tops[j] {
+				res = append(res, newBox(lo, j, lo[j]+1, tops[j], tops))
+			}
+		}
+		from++
+	}
+
+	// Addresses from hi[:d+1].0.0... to hi.
+	tail := make([]ipOctets, 0)
+	if !octeq(hi[d+1:], tops[d+1:]) {
+		z := n - 1
+		for z > d+1 && hi[z] == tops[z] {
+			z--
+		}
+		for j := d + 1; j < z; j++ {
+			if hi[j] > 0 {
+				tail = append(tail, newBox(hi, j, 0, hi[j]-1, tops))
+			}
+		}
+		tail = append(tail, newBox(hi, z, 0, hi[z], tops))
+		to--
+	}
+
+	if from <= to {
+		res = append(res, newBox(lo, d, uint16(from), uint16(to), tops))
+	}
+
+	return append(res, tail...)
+}
+
+// newBox returns box with octets before i fixed to prefix values,
+// i-th octet in [lo, hi] and the rest octets in [0, top].
+func newBox(prefix []uint16, i int, lo, hi uint16, tops []uint16) ipOctets {
+	box := make(ipOctets, len(prefix))
+	for j := range box {
+		switch {
+		case j < i:
+			box[j] = []ipOctet{{prefix[j], prefix[j]}}
+		case j == i:
+			box[j] = []ipOctet{{lo, hi}}
+		default:
+			box[j] = []ipOctet{{0, tops[j]}}
+		}
+	}
+	return box
+}
+
+// fromBoxes returns the most compact range of addresses in disjoint boxes bb.
+func fromBoxes(bb []ipOctets) Range {
+	bb = mergeBoxes(bb)
+
+	// IPv4 first, then by the lowest address.
+	sort.Slice(bb, func(i, j int) bool {
+		if len(bb[i]) != len(bb[j]) {
+			return len(bb[i]) < len(bb[j])
+		}
+		return octcmp(bb[i].min(), bb[j].min()) < 0
+	})
+
+	rr := make(Ranges, 0, len(bb))
+
+	// Bounds of the last range if it is contiguous.
+	var lo, hi []uint16
+
+	for _, b := range bb {
+		if !b.isInterval() {
+			rr = append(rr, &octetsRange{b})
+			lo, hi = nil, nil
+			continue
+		}
+
+		// Join adjacent contiguous ranges.
+		if hi != nil && len(hi) == len(b) {
+			if next, ok := octinc(hi, b.top()); ok && octcmp(next, b.min()) == 0 {
+				hi = b.max()
+				rr[len(rr)-1] = interval2range(lo, hi)
+				continue
+			}
+		}
+
+		lo, hi = b.min(), b.max()
+		rr = append(rr, interval2range(lo, hi))
+	}
+
+	if len(rr) == 1 {
+		return rr[0]
+	}
+
+	return rr
+}
+
+// mergeBoxes merges disjoint boxes which differ only in one octet
+// until there are no such boxes. Boxes are grouped by all octets but one.
+func mergeBoxes(bb []ipOctets) []ipOctets {
+	width := 0
+	for _, b := range bb {
+		if len(b) > width {
+			width = len(b)
+		}
+	}
+
+	for merged := true; merged; {
+		merged = false
+		for k := 0; k < width; k++ {
+			groups := make(map[string]int, len(bb))
+			res := make([]ipOctets, 0, len(bb))
+			joined := make([]bool, 0, len(bb))
+			for _, b := range bb {
+				if k >= len(b) {
+					res = append(res, b)
+					joined = append(joined, false)
+					continue
+				}
+
+				key := boxKey(b, k)
+				i, ok := groups[key]
+				if !ok {
+					groups[key] = len(res)
+					res = append(res, b)
+					joined = append(joined, false)
+					continue
+				}
+
+				if !joined[i] {
+					// Copy the box before changing it.
+					box := make(ipOctets, len(res[i]))
+					copy(box, res[i])
+					box[k] = append([]ipOctet{}, res[i][k]...)
+					res[i], joined[i] = box, true
+				}
+				res[i][k] = append(res[i][k], b[k]...)
+				merged = true
+			}
+
+			for i, b := range res {
+				if joined[i] {
+					b[k] = mergeBounds(b[k])
+				}
+			}
+			bb = res
+		}
+	}
+	return bb
+}
+
+// boxKey returns the box bounds of all octets but k-th one as a string.
+func boxKey(b ipOctets, k int) string {
+	key := make([]byte, 0, 6*len(b))
+	key = append(key, byte(len(b)))
+	for i, oct := range b {
+		if i == k {
+			continue
+		}
+		key = append(key, byte(len(oct)>>8), byte(len(oct)))
+		for _, o := range oct {
+			key = append(key, byte(o.lo>>8), byte(o.lo), byte(o.hi>>8), byte(o.hi))
+		}
+	}
+	return string(key)
+}
+
+// box2range returns range of addresses of the box.
+func box2range(b ipOctets) Range {
+	if !b.hasRanges() {
+		return &singleRange{octets2ip(b.min())}
+	}
+	if b.isInterval() {
+		return &minMaxRange{octets2ip(b.min()), octets2ip(b.max())}
+	}
+	return &octetsRange{b}
+}
+
+// interval2range returns range of addresses between lo and hi inclusive.
+func interval2range(lo, hi []uint16) Range {
+	if octcmp(lo, hi) == 0 {
+		return &singleRange{octets2ip(lo)}
+	}
+	return &minMaxRange{octets2ip(lo), octets2ip(hi)}
+}
@@ -0,0 +1,197 @@
+package iprange_test
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+// parseRanges parses every spec and combines the results.
+func parseRanges(t *testing.T, ss ...string) iprange.Ranges {
+	rr := make(iprange.Ranges, 0)
+	for _, s := range ss {
+		r := iprange.Parse(s)
+		require.NotNil(t, r, s)
+		rr = append(rr, r)
+	}
+	return rr
+}
+
+// addresses returns all addresses of the range as strings.
+func addresses(r iprange.Range) []string {
+	return collect(r.Iterator())
+}
+
+// collect returns all addresses left in the iterator as strings.
+func collect(it iprange.Iterator) []string {
+	res := make([]string, 0)
+	var ip net.IP
+	for it.Next(&ip) {
+		res = append(res, ip.String())
+	}
+	return res
+}
+
+func TestSetOperations(t *testing.T) {
+	tests := []struct {
+		a, b []string
+	}{
+		// IPv4
+		{[]string{"10.0.0.0/29"}, []string{"10.0.0.4/30"}},
+		{[]string{"10.0.0.0/29"}, []string{"10.0.0.8/29"}},
+		{[]string{"10.0.0.0/29"}, []string{"10.0.1.0/29"}},
+		{[]string{"10.0.0.250_10.0.1.5"}, []string{"10.0.0.255"}},
+		{[]string{"10.0.0.250_10.0.1.5"}, []string{"10.0.0-1.1-3,253"}},
+		{[]string{"10.0.1-3.1-10"}, []string{"10.0.2.5-20"}},
+		{[]string{"10.0.1-3.1-10"}, []string{"10.0.2.5-20", "10.0.1.0_10.0.1.2"}},
+		{[]string{"10.0.1,3.1-5", "10.0.1.3-8"}, []string{"10.0.1.4"}},
+		{[]string{"10.0.1.1-5,3-7"}, []string{"10.0.1.2"}},
+		{[]string{"10.0.0.1"}, []string{"10.0.0.1"}},
+
+		// IPv6
+		{[]string{"2001:db8::/124"}, []string{"2001:db8::4-6,a"}},
+		{[]string{"2001:db8::fff0_2001:db8::1:10"}, []string{"2001:db8::1:0/124"}},
+
+		// Mixed
+		{[]string{"10.0.0.0/30", "2001:db8::/126"}, []string{"10.0.0.1", "2001:db8::2"}},
+	}
+
+	ops := []struct {
+		name string
+		fn   func(a, b iprange.Range) iprange.Range
+		in   func(a, b bool) bool
+	}{
+		{"union", iprange.Union, func(a, b bool) bool { return a || b }},
+		{"intersect", iprange.Intersect, func(a, b bool) bool { return a && b }},
+		{"subtract", iprange.Subtract, func(a, b bool) bool { return a && !b }},
+	}
+
+	for i, tt := range tests {
+		for _, op := range ops {
+			name := fmt.Sprintf("%d/%s/%s/%s", i, op.name, strings.Join(tt.a, ","), strings.Join(tt.b, ","))
+			t.Run(name, func(t *testing.T) {
+				a := parseRanges(t, tt.a...)
+				b := parseRanges(t, tt.b...)
+
+				// Expected addresses.
+				seen := make(map[string]bool)
+				expected := make([]string, 0)
+				for _, s := range append(addresses(a), addresses(b)...) {
+					if seen[s] {
+						continue
+					}
+					seen[s] = true
+					ip := net.ParseIP(s)
+					if op.in(a.Contains(ip), b.Contains(ip)) {
+						expected = append(expected, s)
+					}
+				}
+
+				r := op.fn(a, b)
+				require.NotNil(t, r)
+
+				res := addresses(r)
+
+				assert.EqualValues(t, len(expected), r.Count().Int64())
+				assert.ElementsMatch(t, expected, res)
+
+				for _, s := range res {
+					assert.True(t, r.Contains(net.ParseIP(s)), s)
+				}
+			})
+		}
+	}
+}
+
+func TestSetOperationsCompact(t *testing.T) {
+	tests := []struct {
+		op    string
+		a, b  string
+		res   []string
+		count *big.Int
+	}{
+		{"union", "10.0.0.0/24", "10.0.1.0/24", []string{"10.0.0.0", "10.0.1.255"}, big.NewInt(512)},
+		{"union", "10.0.0.0/24", "10.0.0.0/16", []string{"10.0.0.0", "10.0.255.255"}, big.NewInt(65536)},
+		{"intersect", "10.0.0.0/16", "10.0.5.0_10.0.9.255", []string{"10.0.5.0", "10.0.9.255"}, big.NewInt(1280)},
+		{"subtract", "2001:db8::/32", "2001:db8::/33", []string{"2001:db8:8000::", "2001:db8:ffff:ffff:ffff:ffff:ffff:ffff"},
+			new(big.Int).Lsh(big.NewInt(1), 95)},
+	}
+
+	for i, tt := range tests {
+		t.Run(fmt.Sprintf("%d/%s/%s/%s", i, tt.op, tt.a, tt.b), func(t *testing.T) {
+			fn := map[string]func(a, b iprange.Range) iprange.Range{
+				"union":     iprange.Union,
+				"intersect": iprange.Intersect,
+				"subtract":  iprange.Subtract,
+			}[tt.op]
+
+			r := fn(iprange.Parse(tt.a), iprange.Parse(tt.b))
+			assert.Equal(t, tt.count, r.Count())
+
+			_, ok := r.(iprange.Ranges)
+			assert.False(t, ok, "expected single range")
+
+			for _, s := range tt.res {
+				assert.True(t, r.Contains(net.ParseIP(s)), s)
+			}
+		})
+	}
+}
+
+func TestSubtractEmpty(t *testing.T) {
+	r := iprange.Subtract(iprange.Parse("10.0.0.0/24"), iprange.Parse("10.0.0.0/16"))
+	assert.Equal(t, big.NewInt(0), r.Count())
+	assert.Empty(t, addresses(r))
+}
+
+func TestSetOperationsSorted(t *testing.T) {
+	r := iprange.Union(iprange.Parse("10.0.0.5"), parseRanges(t, "2001:db8::1", "10.0.0.1"))
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.5", "2001:db8::1"}, addresses(r))
+}
+
+func TestSetOperationsLarge(t *testing.T) {
+	a, b := benchmarkFeed(3000), benchmarkFeed(1000)
+
+	union := iprange.Union(a, b)
+	assert.Equal(t, iprange.NewIPSet(a).Count(), iprange.Ranges{a}.Normalize().Count())
+	assert.Equal(t, iprange.NewIPSet(union).Count(), union.Count())
+
+	sub := iprange.Subtract(a, b)
+	inter := iprange.Intersect(a, b)
+	assert.Equal(t, union.Count(), new(big.Int).Add(sub.Count(), iprange.NewIPSet(b).Count()))
+	assert.Equal(t, iprange.NewIPSet(a).Count(), new(big.Int).Add(sub.Count(), inter.Count()))
+
+	// Random addresses and the first addresses of ranges.
+	ips := benchmarkAddrs(1000)
+	for _, r := range append(a[:200:200], b[:200]...) {
+		var ip net.IP
+		r.Iterator().Next(&ip)
+		ips = append(ips, ip)
+	}
+
+	for _, ip := range ips {
+		in, out := a.Contains(ip), b.Contains(ip)
+		assert.Equal(t, in || out, union.Contains(ip), ip)
+		assert.Equal(t, in && !out, sub.Contains(ip), ip)
+		assert.Equal(t, in && out, inter.Contains(ip), ip)
+	}
+}
+
+func BenchmarkUnion(b *testing.B) {
+	for _, n := range []int{100, 50000} {
+		x, y := benchmarkFeed(n), benchmarkFeed(n/2)
+
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				iprange.Union(x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package iprange
+
+import (
+	"math/big"
+)
+
+// Shard returns the index-th of total disjoint parts of the range, so the range
+// can be split between multiple workers without enumerating the addresses.
+// Parts differ in size by one address at most and, taken in order,
+// contain all addresses of the range in its iteration order.
+// Returns nil if total is not positive or index is not in [0, total).
+func Shard(r Range, index, total int) Range {
+	if total <= 0 || index < 0 || index >= total {
+		return nil
+	}
+
+	members := flatten(Ranges{r})
+
+	n := big.NewInt(0)
+	for _, m := range members {
+		n.Add(n, count(m))
+	}
+
+	// Indexes of the part addresses: [lo, hi).
+	lo := new(big.Int).Mul(n, big.NewInt(int64(index)))
+	lo.Quo(lo, big.NewInt(int64(total)))
+	hi := new(big.Int).Mul(n, big.NewInt(int64(index+1)))
+	hi.Quo(hi, big.NewInt(int64(total)))
+
+	rr := make(Ranges, 0)
+	offset := big.NewInt(0)
+	one := big.NewInt(1)
+
+	for _, m := range members {
+		end := new(big.Int).Add(offset, count(m))
+
+		// Intersection of the part and the member.
+		from, to := maxBig(lo, offset), minBig(hi, end)
+		if from.Cmp(to) < 0 {
+			first, _ := u128FromBig(from.Sub(from, offset))
+			last, _ := u128FromBig(to.Sub(to, offset).Sub(to, one))
+			rr = append(rr, m.slice(first, last))
+		}
+
+		offset = end
+	}
+
+	if len(rr) == 1 {
+		return rr[0]
+	}
+
+	return rr
+}
+
+// returns copy of the bigger number.
+func maxBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) > 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
+
+// returns copy of the smaller number.
+func minBig(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
@@ -0,0 +1,79 @@
+package iprange_test
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/russtone/iprange"
+)
+
+func TestShard(t *testing.T) {
+	tests := [][]string{
+		{"10.0.0.1"},
+		{"10.0.0.0/24"},
+		{"10.0.0.250_10.0.1.5"},
+		{"10.0.1-3,7.1-3,20-22"},
+		{"10.0-2.0-1,5.1-255"},
+		{"2001:db8::1-2:fffe-ffff,1-2"},
+		{"10.0.0.1", "10.0.0.0/29", "10.0.1-2.1-3", "2001:db8::/126"},
+	}
+
+	for i, tt := range tests {
+		for _, total := range []int{1, 2, 3, 5, 7, 16} {
+			t.Run(fmt.Sprintf("%d/%s/%d", i, strings.Join(tt, ","), total), func(t *testing.T) {
+				rr := parseRanges(t, tt...)
+
+				res := make([]string, 0)
+				count := big.NewInt(0)
+				min, max := rr.Count(), big.NewInt(0)
+
+				for k := 0; k < total; k++ {
+					s := iprange.Shard(rr, k, total)
+					require.NotNil(t, s)
+
+					addrs := addresses(s)
+					assert.EqualValues(t, len(addrs), s.Count().Int64())
+					res = append(res, addrs...)
+
+					c := s.Count()
+					count.Add(count, c)
+					if c.Cmp(min) < 0 {
+						min = c
+					}
+					if c.Cmp(max) > 0 {
+						max = c
+					}
+				}
+
+				assert.Equal(t, rr.Count(), count)
+				assert.Equal(t, addresses(rr), res)
+				assert.True(t, new(big.Int).Sub(max, min).Cmp(big.NewInt(1)) <= 0, "balanced")
+			})
+		}
+	}
+}
+
+func TestShardHuge(t *testing.T) {
+	r := iprange.Parse("2001:db8::/32")
+
+	s := iprange.Shard(r, 1, 3)
+	require.NotNil(t, s)
+
+	n := new(big.Int).Lsh(big.NewInt(1), 96)
+	lo := new(big.Int).Quo(n, big.NewInt(3))
+	hi := new(big.Int).Quo(new(big.Int).Mul(n, big.NewInt(2)), big.NewInt(3))
+	assert.Equal(t, new(big.Int).Sub(hi, lo), s.Count())
+	assert.Equal(t, "2001:db8:5555:5555:5555:5555:5555:5555_2001:db8:aaaa:aaaa:aaaa:aaaa:aaaa:aaa9", fmt.Sprint(s))
+}
+
+func TestShardInvalid(t *testing.T) {
+	r := iprange.Parse("10.0.0.0/24")
+	assert.Nil(t, iprange.Shard(r, 0, 0))
+	assert.Nil(t, iprange.Shard(r, -1, 2))
+	assert.Nil(t, iprange.Shard(r, 2, 2))
+}
@@ -0,0 +1,232 @@
+package iprange
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Table maps ranges of IP-addresses to values and finds the value
+// of the most specific range containing an address, i.e. longest-prefix match.
+// Ranges are split into CIDR prefixes, so the specificity of a range is
+// the length of its prefix containing the address. If prefixes of multiple ranges
+// coincide, the range inserted last wins, the others are kept to match again after it is deleted.
+// The zero Table is empty and ready to use.
+// Table is not safe for concurrent modification.
+type Table[V any] struct {
+	v4, v6 *tableNode[V]
+}
+
+// tableEntry is a range inserted into the table and its value.
+type tableEntry[V any] struct {
+	r    Range
+	v    V
+	cidr string // prefixes of the range, equal for ranges with the same addresses
+}
+
+// tableNode is a node of path-compressed binary trie of prefixes.
+// Prefix bits are aligned to the most significant bit of the key.
+type tableNode[V any] struct {
+	key      uint128
+	bits     int
+	entries  []*tableEntry[V] // in insertion order, empty for nodes which only join children
+	children [2]*tableNode[V]
+}
+
+// Insert maps every address of the range to the value.
+func (t *Table[V]) Insert(r Range, v V) {
+	nn := ToCIDRs(r)
+	e := &tableEntry[V]{r, v, cidrsKey(nn)}
+	for _, n := range nn {
+		key, bits, root := t.prefix(n)
+		*root = (*root).insert(key, bits, e)
+	}
+}
+
+// Lookup returns the value of the most specific range containing the address and the range,
+// false if there is no such range.
+func (t *Table[V]) Lookup(ip net.IP) (V, Range, bool) {
+	addr, _ := netip.AddrFromSlice(ip)
+	return t.LookupAddr(addr)
+}
+
+// LookupAddr is like Lookup, but takes netip.Addr.
+func (t *Table[V]) LookupAddr(addr netip.Addr) (V, Range, bool) {
+	var (
+		zero V
+		key  uint128
+		n    *tableNode[V]
+		best *tableEntry[V]
+	)
+
+	addr = addr.Unmap()
+	switch {
+	case addr.Is4():
+		key, n = u128FromAddr(addr).lsh(96), t.v4
+	case addr.Is6():
+		key, n = u128FromAddr(addr), t.v6
+	}
+
+	for n != nil && key.commonPrefixLen(n.key) >= n.bits {
+		if len(n.entries) > 0 {
+			best = n.entries[len(n.entries)-1]
+		}
+		if n.bits == 128 {
+			break
+		}
+		n = n.children[key.bit(n.bits)]
+	}
+
+	if best == nil {
+		return zero, nil, false
+	}
+
+	return best.v, best.r, true
+}
+
+// Delete removes the range with the same addresses as r from the table, in any notation,
+// so ranges inserted before it and less specific ranges match addresses of the range again.
+// If such range was inserted several times, the earliest insertion is removed.
+// Returns false if there was no such range.
+func (t *Table[V]) Delete(r Range) bool {
+	nn := ToCIDRs(r)
+	cidr := cidrsKey(nn)
+	deleted := false
+	for _, n := range nn {
+		key, bits, root := t.prefix(n)
+		var ok bool
+		if *root, ok = (*root).delete(key, bits, cidr); ok {
+			deleted = true
+		}
+	}
+	return deleted
+}
+
+// Walk calls fn for every range in the table and its value until fn returns false.
+// Ranges are visited in ascending order of their first prefixes, IPv4 first.
+func (t *Table[V]) Walk(fn func(Range, V) bool) {
+	seen := make(map[*tableEntry[V]]bool)
+	for _, root := range []*tableNode[V]{t.v4, t.v6} {
+		if !root.walk(func(e *tableEntry[V]) bool {
+			if seen[e] {
+				return true
+			}
+			seen[e] = true
+			return fn(e.r, e.v)
+		}) {
+			return
+		}
+	}
+}
+
+// cidrsKey returns the prefixes as a string.
+func cidrsKey(nn []*net.IPNet) string {
+	ss := make([]string, len(nn))
+	for i, n := range nn {
+		ss[i] = n.String()
+	}
+	return strings.Join(ss, " ")
+}
+
+// prefix returns the key and the length of the prefix in bits aligned to 128 bits,
+// and the root of the trie of its family.
+func (t *Table[V]) prefix(n *net.IPNet) (uint128, int, **tableNode[V]) {
+	ones, bits := n.Mask.Size()
+	if bits == 8*net.IPv4len {
+		return u128FromIP(n.IP.To4()).lsh(96), ones, &t.v4
+	}
+	return u128FromIP(n.IP), ones, &t.v6
+}
+
+// insert returns the subtree with the prefix mapped to the entry.
+func (n *tableNode[V]) insert(key uint128, bits int, e *tableEntry[V]) *tableNode[V] {
+	if n == nil {
+		return &tableNode[V]{key: key, bits: bits, entries: []*tableEntry[V]{e}}
+	}
+
+	common := key.commonPrefixLen(n.key)
+	if common > bits {
+		common = bits
+	}
+	if common > n.bits {
+		common = n.bits
+	}
+
+	switch {
+	case common == n.bits && common == bits:
+		// The same prefix.
+		n.entries = append(n.entries, e)
+		return n
+	case common == n.bits:
+		// The node prefix contains the prefix.
+		i := key.bit(n.bits)
+		n.children[i] = n.children[i].insert(key, bits, e)
+		return n
+	case common == bits:
+		// The prefix contains the node prefix.
+		parent := &tableNode[V]{key: key, bits: bits, entries: []*tableEntry[V]{e}}
+		parent.children[n.key.bit(bits)] = n
+		return parent
+	}
+
+	// Prefixes diverge, join them with a new node.
+	parent := &tableNode[V]{key: key.prefix(common), bits: common}
+	parent.children[key.bit(common)] = &tableNode[V]{key: key, bits: bits, entries: []*tableEntry[V]{e}}
+	parent.children[n.key.bit(common)] = n
+	return parent
+}
+
+// delete returns the subtree without the earliest entry of the prefix with the range of prefixes cidr,
+// false if there was no such entry.
+func (n *tableNode[V]) delete(key uint128, bits int, cidr string) (*tableNode[V], bool) {
+	if n == nil || bits < n.bits || key.commonPrefixLen(n.key) < n.bits {
+		return n, false
+	}
+
+	if bits == n.bits {
+		for i, e := range n.entries {
+			if e.cidr == cidr {
+				n.entries = append(n.entries[:i:i], n.entries[i+1:]...)
+				return n.compact(), true
+			}
+		}
+		return n, false
+	}
+
+	i := key.bit(n.bits)
+	child, ok := n.children[i].delete(key, bits, cidr)
+	if !ok {
+		return n, false
+	}
+	n.children[i] = child
+
+	return n.compact(), true
+}
+
+// compact returns the subtree without the node if the node has no entry and does not join children.
+func (n *tableNode[V]) compact() *tableNode[V] {
+	if len(n.entries) > 0 {
+		return n
+	}
+	switch {
+	case n.children[0] == nil:
+		return n.children[1]
+	case n.children[1] == nil:
+		return n.children[0]
+	}
+	return n
+}
+
+// walk calls fn for entries of the subtree in pre-order until fn returns false.
+// Returns false if fn did.
+func (n *tableNode[V]) walk(fn func(*tableEntry[V]) bool) bool {
+	if n == nil {
+		return true
+	}
+	for _, e := range n.entries {
+		if !fn(e) {
+			return false
+		}
+	}
+	return n.children[0].walk(fn) && n.children[1].walk(fn)
+}
@@ -0,0 +1,217 @@
+package iprange_test
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/russtone/iprange"
+)
+
+func TestTable(t *testing.T) {
+	var table iprange.Table[string]
+
+	for _, e := range []struct{ r, v string }{
+		{"10.0.0.0/8", "corp"},
+		{"10.1.0.0/16", "site-1"},
+		{"10.1.2.0/24", "office"},
+		{"10.1.2.10_10.1.2.20", "printers"},
+		{"10.2.0-255.1", "gateways"},
+		{"0.0.0.0/0", "internet"},
+		{"2001:db8::/32", "v6"},
+		{"2001:db8:1::/48", "v6-site"},
+		{"2001:db8:1::1", "v6-host"},
+	} {
+		table.Insert(iprange.Parse(e.r), e.v)
+	}
+
+	tests := []struct {
+		ip string
+		v  string
+		r  string
+		ok bool
+	}{
+		{"10.0.0.1", "corp", "10.0.0.0/8", true},
+		{"10.1.3.1", "site-1", "10.1.0.0/16", true},
+		{"10.1.2.9", "office", "10.1.2.0/24", true},
+		{"10.1.2.10", "printers", "10.1.2.10_10.1.2.20", true},
+		{"10.1.2.20", "printers", "10.1.2.10_10.1.2.20", true},
+		{"10.1.2.21", "office", "10.1.2.0/24", true},
+		{"10.2.77.1", "gateways", "10.2.0-255.1", true},
+		{"10.2.77.2", "corp", "10.0.0.0/8", true},
+		{"8.8.8.8", "internet", "0.0.0.0/0", true},
+		{"::ffff:10.1.2.15", "printers", "10.1.2.10_10.1.2.20", true},
+		{"2001:db8::1", "v6", "2001:db8::/32", true},
+		{"2001:db8:1::2", "v6-site", "2001:db8:1::/48", true},
+		{"2001:db8:1::1", "v6-host", "2001:db8:1::1", true},
+		{"2001:db9::1", "", "", false},
+		{"::", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			v, r, ok := table.Lookup(net.ParseIP(tt.ip))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.v, v)
+			if tt.ok {
+				assert.Equal(t, tt.r, fmt.Sprint(r))
+			} else {
+				assert.Nil(t, r)
+			}
+
+			v, _, ok = table.LookupAddr(netip.MustParseAddr(tt.ip))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.v, v)
+		})
+	}
+
+	_, _, ok := table.Lookup(nil)
+	assert.False(t, ok)
+}
+
+func TestTableDelete(t *testing.T) {
+	var table iprange.Table[int]
+
+	table.Insert(iprange.Parse("10.0.0.0/8"), 1)
+	table.Insert(iprange.Parse("10.1.0.0/16"), 2)
+	table.Insert(iprange.Parse("10.1.1,3.0-255"), 3)
+	table.Insert(iprange.Parse("10.1.0.0/24"), 4)
+
+	lookup := func(ip string) int {
+		v, _, _ := table.Lookup(net.ParseIP(ip))
+		return v
+	}
+
+	assert.Equal(t, 3, lookup("10.1.3.7"))
+	assert.Equal(t, 4, lookup("10.1.0.7"))
+
+	assert.True(t, table.Delete(iprange.Parse("10.1.1,3.0-255")))
+	assert.Equal(t, 2, lookup("10.1.3.7"))
+	assert.Equal(t, 4, lookup("10.1.0.7"))
+
+	assert.True(t, table.Delete(iprange.Parse("10.1.0.0/16")))
+	assert.Equal(t, 1, lookup("10.1.3.7"))
+	assert.Equal(t, 4, lookup("10.1.0.7"))
+
+	assert.False(t, table.Delete(iprange.Parse("10.1.0.0/16")))
+	assert.False(t, table.Delete(iprange.Parse("10.2.0.0/16")))
+
+	assert.True(t, table.Delete(iprange.Parse("10.0.0.0/8")))
+	assert.True(t, table.Delete(iprange.Parse("10.1.0.0/24")))
+
+	_, _, ok := table.Lookup(net.ParseIP("10.1.0.7"))
+	assert.False(t, ok)
+
+	table.Walk(func(r iprange.Range, v int) bool {
+		t.Errorf("unexpected range %s", r)
+		return true
+	})
+}
+
+func TestTableInsertReplace(t *testing.T) {
+	var table iprange.Table[string]
+
+	table.Insert(iprange.Parse("10.0.0.0/24"), "a")
+	table.Insert(iprange.Parse("10.0.0.0_10.0.0.255"), "b")
+
+	v, r, ok := table.Lookup(net.ParseIP("10.0.0.1"))
+	assert.True(t, ok)
+	assert.Equal(t, "b", v)
+	assert.Equal(t, "10.0.0.0/24", fmt.Sprint(r))
+}
+
+func TestTableDeleteOverlapping(t *testing.T) {
+	var table iprange.Table[string]
+
+	lookup := func(ip string) string {
+		v, _, _ := table.Lookup(net.ParseIP(ip))
+		return v
+	}
+
+	walk := func() []string {
+		res := make([]string, 0)
+		table.Walk(func(r iprange.Range, v string) bool {
+			res = append(res, fmt.Sprintf("%s=%s", r, v))
+			return true
+		})
+		return res
+	}
+
+	table.Insert(iprange.Parse("10.0.0.0/24"), "a")
+	table.Insert(iprange.Parse("10.0.0.0_10.0.0.255"), "b")
+
+	assert.True(t, table.Delete(iprange.Parse("10.0.0.0/24")))
+	assert.Equal(t, "b", lookup("10.0.0.1"))
+	assert.Equal(t, []string{"10.0.0.0/24=b"}, walk())
+
+	// The second range overwrites the prefix 10.1.0.0/24 of the first one.
+	table.Insert(iprange.Parse("10.1.0.0_10.1.1.127"), "c")
+	table.Insert(iprange.Parse("10.1.0.0/24"), "d")
+	assert.Equal(t, "d", lookup("10.1.0.1"))
+
+	assert.True(t, table.Delete(iprange.Parse("10.1.0.0/24")))
+	assert.Equal(t, "c", lookup("10.1.0.1"))
+	assert.Equal(t, "c", lookup("10.1.1.1"))
+	assert.Equal(t, []string{"10.0.0.0/24=b", "10.1.0.0_10.1.1.127=c"}, walk())
+
+	assert.False(t, table.Delete(iprange.Parse("10.1.0.0/24")))
+	assert.True(t, table.Delete(iprange.Parse("10.1.0.0_10.1.1.127")))
+	assert.Equal(t, "", lookup("10.1.0.1"))
+	assert.Equal(t, []string{"10.0.0.0/24=b"}, walk())
+}
+
+func TestTableDeleteNotation(t *testing.T) {
+	var table iprange.Table[string]
+
+	table.Insert(iprange.Parse("10.0.0.0/24"), "a")
+	table.Insert(iprange.Parse("10.0.1.1,2,3"), "b")
+	table.Insert(iprange.Parse("10.0.0.0/23"), "c")
+
+	assert.True(t, table.Delete(iprange.Parse("10.0.0.0-255")))
+	assert.True(t, table.Delete(iprange.Parse("10.0.1.1_10.0.1.3")))
+	assert.False(t, table.Delete(iprange.Parse("10.0.0.0_10.0.0.255")))
+	assert.False(t, table.Delete(iprange.Parse("10.0.1.0-255")))
+
+	v, _, _ := table.Lookup(net.ParseIP("10.0.1.2"))
+	assert.Equal(t, "c", v)
+}
+
+func TestTableWalk(t *testing.T) {
+	var table iprange.Table[int]
+
+	table.Insert(iprange.Parse("2001:db8::/32"), 1)
+	table.Insert(iprange.Parse("10.0.0.5_10.0.0.20"), 2)
+	table.Insert(iprange.Parse("10.0.0.0/8"), 3)
+	table.Insert(iprange.Parse("192.168.1,3.1"), 4)
+
+	res := make([]string, 0)
+	table.Walk(func(r iprange.Range, v int) bool {
+		res = append(res, fmt.Sprintf("%s=%d", r, v))
+		return true
+	})
+	assert.Equal(t, []string{"10.0.0.0/8=3", "10.0.0.5_10.0.0.20=2", "192.168.1,3.1=4", "2001:db8::/32=1"}, res)
+
+	res = res[:0]
+	table.Walk(func(r iprange.Range, v int) bool {
+		res = append(res, fmt.Sprint(r))
+		return len(res) < 2
+	})
+	assert.Equal(t, []string{"10.0.0.0/8", "10.0.0.5_10.0.0.20"}, res)
+}
+
+func BenchmarkTableLookup(b *testing.B) {
+	var table iprange.Table[int]
+	for i, r := range benchmarkFeed(50000) {
+		table.Insert(r, i)
+	}
+	ips := benchmarkAddrs(1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		table.Lookup(ips[i%len(ips)])
+	}
+}
@@ -0,0 +1,186 @@
+package iprange
+
+import (
+	"encoding/binary"
+	"math/big"
+	"math/bits"
+	"net"
+	"net/netip"
+)
+
+// uint128 is an unsigned 128-bit integer,
+// used instead of math/big for IP-addresses arithmetic.
+type uint128 struct {
+	hi, lo uint64
+}
+
+// u128FromIP returns IP as integer, IP must be 4 or 16 bytes long.
+func u128FromIP(ip net.IP) uint128 {
+	if len(ip) == net.IPv4len {
+		return uint128{0, uint64(binary.BigEndian.Uint32(ip))}
+	}
+	return uint128{binary.BigEndian.Uint64(ip[:8]), binary.BigEndian.Uint64(ip[8:])}
+}
+
+// putIP writes integer into IP, IP must be 4 or 16 bytes long.
+func (u uint128) putIP(ip net.IP) {
+	if len(ip) == net.IPv4len {
+		binary.BigEndian.PutUint32(ip, uint32(u.lo))
+		return
+	}
+	binary.BigEndian.PutUint64(ip[:8], u.hi)
+	binary.BigEndian.PutUint64(ip[8:], u.lo)
+}
+
+// addr returns integer as netip.Addr, iplen is the IP length in bytes.
+func (u uint128) addr(iplen int) netip.Addr {
+	if iplen == net.IPv4len {
+		var ip [net.IPv4len]byte
+		binary.BigEndian.PutUint32(ip[:], uint32(u.lo))
+		return netip.AddrFrom4(ip)
+	}
+
+	var ip [net.IPv6len]byte
+	binary.BigEndian.PutUint64(ip[:8], u.hi)
+	binary.BigEndian.PutUint64(ip[8:], u.lo)
+	return netip.AddrFrom16(ip)
+}
+
+// add64 returns u + n, wrapping around on overflow.
+func (u uint128) add64(n uint64) uint128 {
+	lo, carry := bits.Add64(u.lo, n, 0)
+	return uint128{u.hi + carry, lo}
+}
+
+// sub64 returns u - n, wrapping around on underflow.
+func (u uint128) sub64(n uint64) uint128 {
+	lo, borrow := bits.Sub64(u.lo, n, 0)
+	return uint128{u.hi - borrow, lo}
+}
+
+// cmp compares two integers.
+// Returns 1 if u > v, -1 if u < v and 0 if u = v.
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi > v.hi:
+		return 1
+	case u.hi < v.hi:
+		return -1
+	case u.lo > v.lo:
+		return 1
+	case u.lo < v.lo:
+		return -1
+	}
+	return 0
+}
+
+// u128FromAddr returns address as integer, IPv4-mapped addresses are converted to IPv4.
+func u128FromAddr(addr netip.Addr) uint128 {
+	addr = addr.Unmap()
+	if addr.Is4() {
+		ip := addr.As4()
+		return uint128{0, uint64(binary.BigEndian.Uint32(ip[:]))}
+	}
+	ip := addr.As16()
+	return uint128{binary.BigEndian.Uint64(ip[:8]), binary.BigEndian.Uint64(ip[8:])}
+}
+
+// add returns u + v, wrapping around on overflow.
+func (u uint128) add(v uint128) uint128 {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, _ := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi, lo}
+}
+
+// addOverflow returns u + v and true if the sum overflows.
+func (u uint128) addOverflow(v uint128) (uint128, bool) {
+	lo, carry := bits.Add64(u.lo, v.lo, 0)
+	hi, carry := bits.Add64(u.hi, v.hi, carry)
+	return uint128{hi, lo}, carry != 0
+}
+
+// sub returns u - v, wrapping around on underflow.
+func (u uint128) sub(v uint128) uint128 {
+	lo, borrow := bits.Sub64(u.lo, v.lo, 0)
+	hi, _ := bits.Sub64(u.hi, v.hi, borrow)
+	return uint128{hi, lo}
+}
+
+// mul64 returns u * n, wrapping around on overflow.
+func (u uint128) mul64(n uint64) uint128 {
+	hi, lo := bits.Mul64(u.lo, n)
+	return uint128{hi + u.hi*n, lo}
+}
+
+// divmod64 returns u / n and u % n.
+func (u uint128) divmod64(n uint64) (uint128, uint64) {
+	hi, r := u.hi/n, u.hi%n
+	lo, r := bits.Div64(r, u.lo, n)
+	return uint128{hi, lo}, r
+}
+
+// bitLen returns the minimum number of bits required to represent u.
+func (u uint128) bitLen() int {
+	if u.hi != 0 {
+		return 64 + bits.Len64(u.hi)
+	}
+	return bits.Len64(u.lo)
+}
+
+// lsh returns u << n, n must be less than 128.
+func (u uint128) lsh(n uint) uint128 {
+	if n >= 64 {
+		return uint128{u.lo << (n - 64), 0}
+	}
+	if n == 0 {
+		return u
+	}
+	return uint128{u.hi<<n | u.lo>>(64-n), u.lo << n}
+}
+
+// bit returns i-th bit of u counting from the most significant one.
+func (u uint128) bit(i int) int {
+	if i < 64 {
+		return int(u.hi>>(63-i)) & 1
+	}
+	return int(u.lo>>(127-i)) & 1
+}
+
+// prefix returns u with all bits but the first n most significant ones cleared.
+func (u uint128) prefix(n int) uint128 {
+	switch {
+	case n <= 0:
+		return uint128{}
+	case n < 64:
+		return uint128{u.hi &^ (^uint64(0) >> n), 0}
+	case n < 128:
+		return uint128{u.hi, u.lo &^ (^uint64(0) >> (n - 64))}
+	}
+	return u
+}
+
+// commonPrefixLen returns number of the most significant bits which are equal in u and v.
+func (u uint128) commonPrefixLen(v uint128) int {
+	if x := u.hi ^ v.hi; x != 0 {
+		return bits.LeadingZeros64(x)
+	}
+	return 64 + bits.LeadingZeros64(u.lo^v.lo)
+}
+
+// big returns u as *big.Int.
+func (u uint128) big() *big.Int {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], u.hi)
+	binary.BigEndian.PutUint64(b[8:], u.lo)
+	return new(big.Int).SetBytes(b[:])
+}
+
+// u128FromBig returns n as uint128 and false if n is negative or does not fit.
+func u128FromBig(n *big.Int) (uint128, bool) {
+	if n.Sign() < 0 || n.BitLen() > 128 {
+		return uint128{}, false
+	}
+	var b [16]byte
+	n.FillBytes(b[:])
+	return uint128{binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])}, true
+}
@@ -3,6 +3,7 @@ package iprange
 import (
 	"math/big"
 	"net"
+	"net/netip"
 )
 
 // net.IP to *big.Int
@@ -41,25 +42,153 @@ func ip2octets(ip net.IP) []uint16 {
 	return octs
 }
 
+// net.IP to octets as []uint16, keeping the address length,
+// so 16-byte IPv4-mapped addresses are converted to 8 octets.
+func bytes2octets(ip net.IP) []uint16 {
+	if len(ip) == net.IPv4len {
+		return ip2octets(ip)
+	}
+
+	octs := make([]uint16, net.IPv6len/2)
+	for i := range octs {
+		octs[i] = uint16(ip[2*i])<<8 | uint16(ip[2*i+1])
+	}
+
+	return octs
+}
+
+// octtop returns the biggest value of one octet of IP-address with n octets.
+func octtop(n int) uint16 {
+	if n == net.IPv4len {
+		return 0xff
+	}
+	return 0xffff
+}
+
+// octtops returns the biggest values of every octet of IP-address with length iplen in bytes.
+func octtops(iplen int) []uint16 {
+	n := iplen
+	if iplen != net.IPv4len {
+		n = net.IPv6len / 2
+	}
+
+	tops := make([]uint16, n)
+	for i := range tops {
+		tops[i] = octtop(n)
+	}
+
+	return tops
+}
+
+// octeq returns true if octets are equal.
+func octeq(a, b []uint16) bool {
+	return octcmp(a, b) == 0
+}
+
+// octinc returns octets of the next IP-address, top is the biggest value of one octet.
+// Returns false if there is no next address.
+func octinc(octs []uint16, top uint16) ([]uint16, bool) {
+	next := make([]uint16, len(octs))
+	copy(next, octs)
+
+	for i := len(next) - 1; i >= 0; i-- {
+		if next[i] < top {
+			next[i]++
+			return next, true
+		}
+		next[i] = 0
+	}
+
+	return nil, false
+}
+
 // []uint16 octets to net.IP.
 func octets2ip(octs []uint16) net.IP {
-	iplen := net.IPv4len
-	if len(octs) != net.IPv4len {
-		iplen = net.IPv6len
-	}
+	ip := make(net.IP, octets2iplen(octs))
+	putOctets(ip, octs)
+	return ip
+}
 
-	ip := make(net.IP, iplen)
+// returns length in bytes of IP with the given octets.
+func octets2iplen(octs []uint16) int {
+	if len(octs) == net.IPv4len {
+		return net.IPv4len
+	}
+	return net.IPv6len
+}
 
+// writes []uint16 octets into net.IP of suitable length.
+func putOctets(ip net.IP, octs []uint16) {
 	for i, oct := range octs {
-		if iplen == net.IPv4len {
+		if len(ip) == net.IPv4len {
 			ip[i] = byte(oct)
 		} else {
 			ip[2*i] = byte(oct >> 8)
 			ip[2*i+1] = byte(oct)
 		}
 	}
+}
 
-	return ip
+// reuseIP returns ip resliced to n bytes if it has enough capacity, new IP otherwise.
+func reuseIP(ip net.IP, n int) net.IP {
+	if cap(ip) >= n {
+		return ip[:n]
+	}
+	return make(net.IP, n)
+}
+
+// ipbit returns i-th bit of IP, starting from the most significant one.
+func ipbit(ip net.IP, i int) byte {
+	return ip[i/8] >> (7 - uint(i%8)) & 1
+}
+
+// ip2string returns textual representation of IP which Parse accepts.
+// Unlike net.IP.String, 16-byte IPv4-mapped addresses are formatted as IPv6.
+func ip2string(ip net.IP) string {
+	if len(ip) == net.IPv6len && ip.To4() != nil {
+		octs := make(ipOctets, 0, net.IPv6len/2)
+		for _, oct := range bytes2octets(ip) {
+			octs = append(octs, []ipOctet{{oct, oct}})
+		}
+		return octs.format()
+	}
+	return ip.String()
+}
+
+// netip.Addr to octets, IPv4-mapped addresses are converted to IPv4.
+// Returns octets and their number.
+func addr2octets(addr netip.Addr) (octs [net.IPv6len / 2]uint16, n int) {
+	return addrOctets(addr.Unmap())
+}
+
+// addrOctets is like addr2octets, but keeps IPv4-mapped IPv6 addresses IPv6.
+func addrOctets(addr netip.Addr) (octs [net.IPv6len / 2]uint16, n int) {
+	if addr.Is4() {
+		for i, b := range addr.As4() {
+			octs[i] = uint16(b)
+		}
+		return octs, net.IPv4len
+	}
+
+	ip := addr.As16()
+	for i := range octs {
+		octs[i] = uint16(ip[2*i])<<8 | uint16(ip[2*i+1])
+	}
+	return octs, len(octs)
+}
+
+// []uint16 octets to netip.Addr.
+func octets2addr(octs []uint16) netip.Addr {
+	if len(octs) == net.IPv4len {
+		return netip.AddrFrom4([4]byte{byte(octs[0]), byte(octs[1]), byte(octs[2]), byte(octs[3])})
+	}
+
+	var ip [net.IPv6len]byte
+	for i, oct := range octs {
+		ip[2*i] = byte(oct >> 8)
+		ip[2*i+1] = byte(oct)
+	}
+	return netip.AddrFrom16(ip)
 }
 
 // Bigger than we need, not too big to worry about overflow